@@ -0,0 +1,306 @@
+package gtiff
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Compression schemes (tag 259).
+const (
+	CompressionNone     uint16 = 1
+	CompressionLZW      uint16 = 5
+	CompressionDeflate  uint16 = 8 // adobe deflate (zlib)
+	CompressionPackBits uint16 = 32773
+
+	compressionDeflateOld uint16 = 32946 // deflate code used before adobe registered 8
+)
+
+// Predictors (tag 317).
+const (
+	PredictorNone          uint16 = 1
+	PredictorHorizontal    uint16 = 2
+	PredictorFloatingPoint uint16 = 3
+)
+
+// compress a strip or tile of raw pixel bytes, rowBytes is the number of bytes in one row
+func compress(scheme uint16, src []byte, rowBytes int) ([]byte, error) {
+	switch scheme {
+	case 0, CompressionNone:
+		return src, nil
+	case CompressionLZW:
+		return lzwEncode(src), nil
+	case CompressionDeflate, compressionDeflateOld:
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		if _, err := zw.Write(src); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionPackBits:
+		// each row is packed separately
+		var out []byte
+		for i := 0; i < len(src); i += rowBytes {
+			end := i + rowBytes
+			if end > len(src) {
+				end = len(src)
+			}
+			out = packBits(out, src[i:end])
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%w, got %d", ErrUnsupportedCompression, scheme)
+}
+
+// decompress a strip or tile into dst and return the number of bytes written
+func decompress(scheme uint16, dst, src []byte) (int, error) {
+	switch scheme {
+	case 0, CompressionNone:
+		return copy(dst, src), nil
+	case CompressionLZW:
+		return lzwDecode(dst, src)
+	case CompressionDeflate, compressionDeflateOld:
+		return inflate(dst, src)
+	case CompressionPackBits:
+		return unpackBits(dst, src)
+	}
+	return 0, fmt.Errorf("%w, got %d", ErrUnsupportedCompression, scheme)
+}
+
+// the fewest bytes of data compressed with scheme that can decode to n bytes, 0 for an unsupported
+// scheme. Deflate decodes a byte to at most 1032, an LZW code of 12 bits to at most 4094 and
+// PackBits two bytes to 128.
+func minCompressed(scheme uint16, n uint64) uint64 {
+	var ratio uint64
+	switch scheme {
+	case 0, CompressionNone:
+		ratio = 1
+	case CompressionLZW:
+		ratio = 2730
+	case CompressionDeflate, compressionDeflateOld:
+		ratio = 1032
+	case CompressionPackBits:
+		ratio = 64
+	default:
+		return 0
+	}
+	return (n + ratio - 1) / ratio
+}
+
+// flate readers reused between strips or tiles, creating one allocates its large window
+var inflaters sync.Pool
+
+type inflater struct {
+	src *bytes.Reader
+	fr  io.ReadCloser
+}
+
+// decode zlib src into dst and return the number of bytes written. The zlib header is checked here
+// and the data inflated with compress/flate, as resetting a zlib reader allocates a new checksum.
+// The checksum after the data is not checked, as with zlib when dst is filled before the end.
+func inflate(dst, src []byte) (int, error) {
+	if len(src) < 2 || src[0]&0x0f != 8 || (uint16(src[0])<<8|uint16(src[1]))%31 != 0 {
+		return 0, fmt.Errorf("deflate: %w, %v", ErrCorrupt, zlib.ErrHeader)
+	}
+	if src[1]&0x20 != 0 {
+		return 0, fmt.Errorf("deflate: %w, %v", ErrCorrupt, zlib.ErrDictionary)
+	}
+	src = src[2:]
+
+	f, _ := inflaters.Get().(*inflater)
+	if f == nil {
+		f = &inflater{src: bytes.NewReader(src)}
+		f.fr = flate.NewReader(f.src)
+	} else {
+		f.src.Reset(src)
+		if err := f.fr.(flate.Resetter).Reset(f.src, nil); err != nil {
+			return 0, fmt.Errorf("deflate: %w, %v", ErrCorrupt, err)
+		}
+	}
+	defer inflaters.Put(f)
+
+	n, err := io.ReadFull(f.fr, dst)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		return n, nil // short data is reported by the caller
+	}
+	if err != nil {
+		return n, fmt.Errorf("deflate: %w, %v", ErrCorrupt, err)
+	}
+	return n, nil
+}
+
+// append packbits encoding of src to dst
+func packBits(dst, src []byte) []byte {
+	for i := 0; i < len(src); {
+		// length of run of the same byte starting at i
+		run := 1
+		for i+run < len(src) && run < 128 && src[i+run] == src[i] {
+			run++
+		}
+		if run > 1 {
+			dst = append(dst, byte(1-run), src[i])
+			i += run
+			continue
+		}
+
+		// literal bytes until the next run of at least 3 bytes
+		lit := 1
+		for i+lit < len(src) && lit < 128 {
+			if i+lit+2 < len(src) && src[i+lit] == src[i+lit+1] && src[i+lit] == src[i+lit+2] {
+				break
+			}
+			lit++
+		}
+		dst = append(dst, byte(lit-1))
+		dst = append(dst, src[i:i+lit]...)
+		i += lit
+	}
+	return dst
+}
+
+// decode packbits src into dst and return the number of bytes written
+func unpackBits(dst, src []byte) (int, error) {
+	n := 0
+	for i := 0; i < len(src) && n < len(dst); {
+		h := int8(src[i])
+		i++
+		switch {
+		case h >= 0:
+			// literal run of h+1 bytes
+			l := int(h) + 1
+			if i+l > len(src) {
+				return n, fmt.Errorf("packbits: %w, literal run past end of data", ErrCorrupt)
+			}
+			n += copy(dst[n:], src[i:i+l])
+			i += l
+		case h != -128:
+			// byte repeated 1-h times
+			if i >= len(src) {
+				return n, fmt.Errorf("packbits: %w, repeat run past end of data", ErrCorrupt)
+			}
+			for l := 1 - int(h); l > 0 && n < len(dst); l-- {
+				dst[n] = src[i]
+				n++
+			}
+			i++
+		}
+	}
+	return n, nil
+}
+
+// apply predictor to a strip or tile of width samples per row before compression
+func applyPredictor(predictor uint16, b []byte, width, bytesPerSample int, byteOrder binary.ByteOrder) error {
+	rowBytes := width * bytesPerSample
+	switch predictor {
+	case 0, PredictorNone:
+		return nil
+	case PredictorHorizontal:
+		for row := 0; row+rowBytes <= len(b); row += rowBytes {
+			horizontalDiff(b[row:row+rowBytes], bytesPerSample, byteOrder)
+		}
+		return nil
+	case PredictorFloatingPoint:
+		scratch := getScratch(rowBytes)
+		defer scratchPool.Put(scratch)
+		tmp := *scratch
+		for row := 0; row+rowBytes <= len(b); row += rowBytes {
+			r := b[row : row+rowBytes]
+			// shuffle bytes of each sample into planes, most significant byte first
+			for i := 0; i < width; i++ {
+				for j := 0; j < bytesPerSample; j++ {
+					k := j
+					if byteOrder == binary.LittleEndian {
+						k = bytesPerSample - j - 1
+					}
+					tmp[j*width+i] = r[i*bytesPerSample+k]
+				}
+			}
+			for i := rowBytes - 1; i > 0; i-- {
+				tmp[i] -= tmp[i-1]
+			}
+			copy(r, tmp)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w, got %d", ErrUnsupportedPredictor, predictor)
+}
+
+// undo predictor of a decompressed strip or tile of width samples per row
+func undoPredictor(predictor uint16, b []byte, width, bytesPerSample int, byteOrder binary.ByteOrder) error {
+	rowBytes := width * bytesPerSample
+	switch predictor {
+	case 0, PredictorNone:
+		return nil
+	case PredictorHorizontal:
+		for row := 0; row+rowBytes <= len(b); row += rowBytes {
+			horizontalSum(b[row:row+rowBytes], bytesPerSample, byteOrder)
+		}
+		return nil
+	case PredictorFloatingPoint:
+		// bytes are reordered through pooled scratch space so reading into a slice does not allocate
+		scratch := getScratch(rowBytes)
+		defer scratchPool.Put(scratch)
+		tmp := *scratch
+		for row := 0; row+rowBytes <= len(b); row += rowBytes {
+			r := b[row : row+rowBytes]
+			for i := 1; i < rowBytes; i++ {
+				r[i] += r[i-1]
+			}
+			copy(tmp, r)
+			for i := 0; i < width; i++ {
+				for j := 0; j < bytesPerSample; j++ {
+					k := j
+					if byteOrder == binary.LittleEndian {
+						k = bytesPerSample - j - 1
+					}
+					r[i*bytesPerSample+k] = tmp[j*width+i]
+				}
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w, got %d", ErrUnsupportedPredictor, predictor)
+}
+
+// replace each sample in a row with its difference from the previous sample
+func horizontalDiff(row []byte, bytesPerSample int, byteOrder binary.ByteOrder) {
+	switch bytesPerSample {
+	case 1:
+		for i := len(row) - 1; i > 0; i-- {
+			row[i] -= row[i-1]
+		}
+	case 2:
+		for i := len(row) - 2; i > 0; i -= 2 {
+			byteOrder.PutUint16(row[i:], byteOrder.Uint16(row[i:])-byteOrder.Uint16(row[i-2:]))
+		}
+	case 4:
+		for i := len(row) - 4; i > 0; i -= 4 {
+			byteOrder.PutUint32(row[i:], byteOrder.Uint32(row[i:])-byteOrder.Uint32(row[i-4:]))
+		}
+	}
+}
+
+// reverse horizontalDiff
+func horizontalSum(row []byte, bytesPerSample int, byteOrder binary.ByteOrder) {
+	switch bytesPerSample {
+	case 1:
+		for i := 1; i < len(row); i++ {
+			row[i] += row[i-1]
+		}
+	case 2:
+		for i := 2; i+2 <= len(row); i += 2 {
+			byteOrder.PutUint16(row[i:], byteOrder.Uint16(row[i:])+byteOrder.Uint16(row[i-2:]))
+		}
+	case 4:
+		for i := 4; i+4 <= len(row); i += 4 {
+			byteOrder.PutUint32(row[i:], byteOrder.Uint32(row[i:])+byteOrder.Uint32(row[i-4:]))
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package gtiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+// example from the packbits section of the tiff 6.0 spec
+var (
+	unpacked = []byte{0xAA, 0xAA, 0xAA, 0x80, 0x00, 0x2A, 0xAA, 0xAA, 0xAA, 0xAA, 0x80, 0x00, 0x2A, 0x22, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA, 0xAA}
+	packed   = []byte{0xFE, 0xAA, 0x02, 0x80, 0x00, 0x2A, 0xFD, 0xAA, 0x03, 0x80, 0x00, 0x2A, 0x22, 0xF7, 0xAA}
+)
+
+func TestPackBits(t *testing.T) {
+	if got := packBits(nil, unpacked); !bytes.Equal(packed, got) {
+		t.Errorf("expected %X, got %X", packed, got)
+	}
+
+	got := make([]byte, len(unpacked))
+	n, err := unpackBits(got, packed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(unpacked) || !bytes.Equal(unpacked, got) {
+		t.Errorf("expected %X, got %X", unpacked, got[:n])
+	}
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	// long runs, noise and enough data to fill the lzw string table several times
+	src := make([]byte, 300000)
+	for i := range src {
+		switch {
+		case i%1000 < 300:
+			src[i] = byte(i / 1000)
+		default:
+			src[i] = byte(rand.Intn(256))
+		}
+	}
+
+	for _, scheme := range []uint16{CompressionNone, CompressionLZW, CompressionDeflate, CompressionPackBits} {
+		for _, size := range []int{0, 1, 2, 1000, len(src)} {
+			c, err := compress(scheme, src[:size], 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]byte, size)
+			n, err := decompress(scheme, got, c)
+			if err != nil {
+				t.Fatalf("compression %d, size %d: %v", scheme, size, err)
+			}
+			if n != size || !bytes.Equal(src[:size], got) {
+				t.Errorf("compression %d, size %d: data changed", scheme, size)
+			}
+		}
+	}
+}
+
+func TestPredictorRoundTrip(t *testing.T) {
+	const width = 13
+	for _, predictor := range []uint16{PredictorHorizontal, PredictorFloatingPoint} {
+		for _, bytesPerSample := range []int{1, 2, 4} {
+			for _, byteOrder := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+				src := make([]byte, width*bytesPerSample*3)
+				rand.Read(src)
+				b := append([]byte{}, src...)
+				if err := applyPredictor(predictor, b, width, bytesPerSample, byteOrder); err != nil {
+					t.Fatal(err)
+				}
+				if err := undoPredictor(predictor, b, width, bytesPerSample, byteOrder); err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(src, b) {
+					t.Errorf("predictor %d, %d bytes per sample, %v: data changed", predictor, bytesPerSample, byteOrder)
+				}
+			}
+		}
+	}
+}
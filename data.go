@@ -3,28 +3,65 @@ package gtiff
 import (
 	"encoding/binary"
 	"fmt"
+	"strings"
+	"time"
 )
 
+// DateTimeFormat is the layout of the DateTime tag for use with time.Format and time.Parse.
+const DateTimeFormat = "2006:01:02 15:04:05"
+
 // Header represents a  parsed tiff header.
 type Header struct {
 	ByteOrder      binary.ByteOrder
-	TiffIdentifier uint16
-	IFDOffset      uint32
+	TiffIdentifier uint16 // 42, or 43 for BigTIFF
+	IFDOffset      uint64
+	BigTIFF        bool // offsets and counts are 64 bit
 }
 
-// Tags holds the minumum grayscale tag set per tiff 6.0 spec.
+// Tags holds the minumum grayscale tag set per tiff 6.0 spec along with tags for compressed and tiled images and common metadata.
 type Tags struct {
 	ImageWidth                uint32   // 256 (short or long)
 	ImageLength               uint32   // 257 (short or long)
 	BitsPerSample             uint16   // 258 (count: single value for grayscale images)
 	Compression               uint16   // 259
 	PhotometricInterpretation uint16   // 262
-	StripOffsets              []uint32 // 273 (short or long) (count: StripsPerImage)
+	StripOffsets              []uint64 // 273 (short, long or long8) (count: StripsPerImage)
 	RowsPerStrip              uint32   // 278 (short or long)
-	StripByteCounts           []uint32 // 279 (short or long) (count: StripsPerImage)
+	StripByteCounts           []uint64 // 279 (short, long or long8) (count: StripsPerImage)
 	XResolution               []uint32 // 282 (count: 2, numerator, denomenator)
 	YResolution               []uint32 // 283 (count: 2, numerator, denomenator)
 	ResolutionUnit            uint16   // 296
+	Predictor                 uint16   // 317
+	TileWidth                 uint32   // 322 (short or long)
+	TileLength                uint32   // 323 (short or long)
+	TileOffsets               []uint64 // 324 (long or long8) (count: TilesPerImage)
+	TileByteCounts            []uint64 // 325 (short, long or long8) (count: TilesPerImage)
+	SampleFormat              uint16   // 339 (1 uint, 3 IEEE floating point)
+
+	// ascii tags, multiple strings in one tag are separated by NUL
+	DocumentName     string // 269
+	ImageDescription string // 270
+	Make             string // 271
+	Model            string // 272
+	PageName         string // 285
+	Software         string // 305
+	DateTime         string // 306 (format: "YYYY:MM:DD HH:MM:SS")
+	Artist           string // 315
+	HostComputer     string // 316
+	Copyright        string // 33432
+
+	// all other tags in the order they were read
+	Extra []Field
+}
+
+// Field holds the raw value of a tag that has no dedicated field in Tags.
+// Values larger than 4 bytes are moved and their offsets rewritten when written to a new file.
+type Field struct {
+	Tag       uint16
+	Type      uint16           // tiff data type (1 byte, 2 ascii, 3 short, 4 long, 5 rational, ...)
+	Count     uint64           // number of values
+	Value     []byte           // raw value bytes
+	ByteOrder binary.ByteOrder // byte order of Value
 }
 
 // String method for Tags
@@ -40,6 +77,51 @@ func (t Tags) String() string {
 	res += fmt.Sprintf("StripByteCounts(279):           %v\n", t.StripByteCounts)
 	res += fmt.Sprintf("XResolution(282):               %v\n", t.XResolution)
 	res += fmt.Sprintf("YResolution(283):               %v\n", t.YResolution)
-	res += fmt.Sprintf("ResolutionUnit(296):            %v", t.ResolutionUnit)
+	res += fmt.Sprintf("ResolutionUnit(296):            %v\n", t.ResolutionUnit)
+	res += fmt.Sprintf("Predictor(317):                 %v\n", t.Predictor)
+	res += fmt.Sprintf("TileWidth(322):                 %v\n", t.TileWidth)
+	res += fmt.Sprintf("TileLength(323):                %v\n", t.TileLength)
+	res += fmt.Sprintf("TileOffsets(324):               %v\n", t.TileOffsets)
+	res += fmt.Sprintf("TileByteCounts(325):            %v\n", t.TileByteCounts)
+	res += fmt.Sprintf("SampleFormat(339):              %v\n", t.SampleFormat)
+	res += fmt.Sprintf("DocumentName(269):              %q\n", t.DocumentName)
+	res += fmt.Sprintf("ImageDescription(270):          %q\n", t.ImageDescription)
+	res += fmt.Sprintf("Make(271):                      %q\n", t.Make)
+	res += fmt.Sprintf("Model(272):                     %q\n", t.Model)
+	res += fmt.Sprintf("PageName(285):                  %q\n", t.PageName)
+	res += fmt.Sprintf("Software(305):                  %q\n", t.Software)
+	res += fmt.Sprintf("DateTime(306):                  %q\n", t.DateTime)
+	res += fmt.Sprintf("Artist(315):                    %q\n", t.Artist)
+	res += fmt.Sprintf("HostComputer(316):              %q\n", t.HostComputer)
+	res += fmt.Sprintf("Copyright(33432):               %q", t.Copyright)
+	for _, f := range t.Extra {
+		res += fmt.Sprintf("\n%-32s%v", fmt.Sprintf("Tag(%d):", f.Tag), f.Value)
+	}
 	return res
 }
+
+// Field returns the first extra field with the given tag.
+func (t Tags) Field(tag uint16) (Field, bool) {
+	for _, f := range t.Extra {
+		if f.Tag == tag {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Stamp records provenance by setting Software and setting DateTime to the current time.
+func (t *Tags) Stamp(software string) {
+	t.Software = software
+	t.DateTime = time.Now().Format(DateTimeFormat)
+}
+
+// Time parses the DateTime tag.
+func (t Tags) Time() (time.Time, error) {
+	return time.ParseInLocation(DateTimeFormat, t.DateTime, time.Local)
+}
+
+// SplitASCII splits the value of an ascii tag into its NUL separated strings.
+func SplitASCII(s string) []string {
+	return strings.Split(s, "\x00")
+}
@@ -0,0 +1,123 @@
+package gtiff
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Errors returned while reading and writing, possibly wrapped with details. Test for them with errors.Is.
+var (
+	// ErrInvalidHeader is returned for a file that does not start with a tiff or BigTIFF header.
+	ErrInvalidHeader = errors.New("invalid tiff header")
+
+	// ErrTruncated is returned when a file ends before a value, IFD, strip or tile it refers to.
+	// Errors matching it also match io.EOF or io.ErrUnexpectedEOF if that is where they came from.
+	ErrTruncated = errors.New("file is truncated")
+
+	// ErrCorrupt is returned for compressed data that can't be decoded.
+	ErrCorrupt = errors.New("corrupt compressed data")
+
+	// ErrUnsupportedCompression is returned for a compression scheme other than none, LZW, deflate or packbits.
+	ErrUnsupportedCompression = errors.New("compression not supported")
+
+	// ErrUnsupportedPredictor is returned for a predictor other than none, horizontal or floating point.
+	ErrUnsupportedPredictor = errors.New("predictor not supported")
+
+	// ErrUnsupportedBitsPerSample is returned for images of other than 8, 16 or 32 bits per sample.
+	ErrUnsupportedBitsPerSample = errors.New("bits per sample not supported")
+
+	// ErrUnsupportedType is returned for a tag of a type that can't hold its value, or by Reader.Data
+	// for samples of a SampleFormat it can't represent.
+	ErrUnsupportedType = errors.New("tag type not supported")
+
+	// ErrWrongBitsPerSample is returned reading an image as samples of a different size, such as
+	// reading a 16 bit image with ReadData8.
+	ErrWrongBitsPerSample = errors.New("wrong bits per sample")
+
+	// ErrInvalidLayout is returned when the strip or tile tags don't describe the image.
+	ErrInvalidLayout = errors.New("invalid strip or tile layout")
+
+	// ErrLimit is returned when a file exceeds its Limits.
+	ErrLimit = errors.New("limit exceeded")
+
+	// ErrSpecViolation is returned in ModeStrict, or passed to ReadOptions.Warn, for a file
+	// that does not follow the tiff spec.
+	ErrSpecViolation = errors.New("violates the tiff spec")
+
+	// ErrDataSize is returned writing a slice of data that does not hold exactly width*length samples.
+	ErrDataSize = errors.New("data does not match image size")
+
+	// ErrVerify is returned by an Encoder with Options.Verify when the file read back does not hold
+	// the data written.
+	ErrVerify = errors.New("file read back does not match data written")
+
+	// ErrOutOfRange is returned for a page, strip, tile or region that is not in the file or image.
+	ErrOutOfRange = errors.New("out of range")
+)
+
+// IFDError reports an IFD offset that can't be followed, such as one outside the file
+// or one pointing back to an IFD already read.
+type IFDError struct {
+	Offset uint64 // offset of the IFD in the file
+	Reason string
+}
+
+func (e *IFDError) Error() string {
+	return fmt.Sprintf("parse: IFD at offset %d %s", e.Offset, e.Reason)
+}
+
+// TagError reports a tag whose value can't be read.
+type TagError struct {
+	Tag    uint16
+	Offset uint64 // offset of the value in the file, 0 for a tag that is missing
+	Err    error
+}
+
+func (e *TagError) Error() string {
+	return fmt.Sprintf("parse: tag %d at offset %d: %v", e.Tag, e.Offset, e.Err)
+}
+
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+// BlockError reports a strip or tile that can't be read or decoded.
+type BlockError struct {
+	Index  int    // index of the strip or tile in the image
+	Offset uint64 // offset of the strip or tile in the file
+	Err    error
+}
+
+func (e *BlockError) Error() string {
+	return fmt.Sprintf("read: block %d at offset %d: %v", e.Index, e.Offset, e.Err)
+}
+
+func (e *BlockError) Unwrap() error {
+	return e.Err
+}
+
+// err with io.EOF and io.ErrUnexpectedEOF wrapped so they match ErrTruncated
+func truncated(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return &truncatedError{err}
+	}
+	return err
+}
+
+// truncatedError matches both ErrTruncated and the io error it wraps
+type truncatedError struct {
+	err error
+}
+
+func (e *truncatedError) Error() string {
+	return ErrTruncated.Error() + ": " + e.err.Error()
+}
+
+func (e *truncatedError) Unwrap() error {
+	return e.err
+}
+
+func (e *truncatedError) Is(target error) bool {
+	return target == ErrTruncated
+}
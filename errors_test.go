@@ -0,0 +1,85 @@
+package gtiff
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	data := make([]uint16, 64*32)
+	for i := range data {
+		data[i] = uint16(i)
+	}
+	f := &memFile{}
+	if err := NewEncoder(f, &Options{Compression: CompressionLZW, RowsPerStrip: 8}).Encode16(data, 64, 32); err != nil {
+		t.Fatal(err)
+	}
+	file := f.buf
+	tags, header, err := ReadTags(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// header
+	if _, _, err := ReadTags(bytes.NewReader([]byte("MZ\x00\x00"))); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("expected ErrInvalidHeader, got %v", err)
+	}
+	if _, _, err := ReadTags(bytes.NewReader(file[:3])); !errors.Is(err, ErrTruncated) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected ErrTruncated matching io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	// strips
+	if _, err := ReadData8(bytes.NewReader(file), header, tags); !errors.Is(err, ErrWrongBitsPerSample) {
+		t.Errorf("expected ErrWrongBitsPerSample, got %v", err)
+	}
+	corrupt := append([]byte(nil), file...)
+	for i := tags.StripOffsets[2]; i < tags.StripOffsets[2]+tags.StripByteCounts[2]; i++ {
+		corrupt[i] = 0xff
+	}
+	_, err = ReadData16(bytes.NewReader(corrupt), header, tags)
+	var blockErr *BlockError
+	if !errors.Is(err, ErrCorrupt) || !errors.As(err, &blockErr) || blockErr.Index != 2 || blockErr.Offset != tags.StripOffsets[2] {
+		t.Errorf("expected ErrCorrupt in block 2, got %v", err)
+	}
+	truncatedTags := tags
+	truncatedTags.StripOffsets = append([]uint64(nil), tags.StripOffsets...)
+	truncatedTags.StripOffsets[3] = uint64(len(file)) - 10
+	if _, err := ReadData16(bytes.NewReader(file), header, truncatedTags); !errors.Is(err, ErrTruncated) || !errors.As(err, &blockErr) || blockErr.Index != 3 {
+		t.Errorf("expected ErrTruncated in block 3, got %v", err)
+	}
+	unsupported := tags
+	unsupported.Compression = 7
+	if _, err := ReadData16(bytes.NewReader(file), header, unsupported); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Errorf("expected ErrUnsupportedCompression, got %v", err)
+	}
+
+	// tags
+	badTag := buildTiff(func(d *ifdBuilder) {
+		d.long(256, 2)
+		d.long(257, 4)
+		d.short(258, 8)
+		d.rational(273, 8, 1)
+		d.long(279, 8)
+	})
+	var tagErr *TagError
+	if _, _, err := ReadTags(bytes.NewReader(badTag)); !errors.Is(err, ErrUnsupportedType) || !errors.As(err, &tagErr) || tagErr.Tag != 273 {
+		t.Errorf("expected ErrUnsupportedType for tag 273, got %v", err)
+	}
+	if _, _, err := ReadTagsLimits(bytes.NewReader(file), &Limits{MaxPixels: 100}); !errors.Is(err, ErrLimit) {
+		t.Errorf("expected ErrLimit, got %v", err)
+	}
+
+	// Reader
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Data16(1); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %v", err)
+	}
+	if _, err := r.Region16(0, 60, 0, 8, 8); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %v", err)
+	}
+}
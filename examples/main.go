@@ -1,26 +1,23 @@
 package main
 
 import (
-	"os"
-
 	"github.com/rngoodner/gtiff"
 )
 
 func main() {
 	// open a tiff file
-	r, _ := os.Open("../test-images/cell32.tif") // error handling omitted
-	defer r.Close()
+	f, _ := gtiff.Open("../test-images/cell32.tif") // error handling omitted
+	defer f.Close()
 
 	// read tags
-	tags, header, _ := gtiff.ReadTags(r) // error handling omitted
+	tags, _ := f.Tags(0) // error handling omitted
 
 	// read data
-	data, _ := gtiff.ReadData32(r, header, tags) // error handling omitted
+	data, _ := f.Data32(0) // error handling omitted
 
 	// >>> manipulate data as desired here <<<
 
-	// write a new tiff
-	w, _ := os.Create("../test-images/example-output-cell32.tif") // error handling omitted
-	defer w.Close()
-	gtiff.WriteTiff32(w, header.ByteOrder, data, tags.ImageWidth, tags.ImageLength) // error handling omitted
+	// write a new tiff, keeping metadata from the original
+	opt := &gtiff.Options{ByteOrder: f.Header().ByteOrder, Metadata: tags}
+	gtiff.WriteFile32("../test-images/example-output-cell32.tif", data, tags.ImageWidth, tags.ImageLength, opt) // error handling omitted
 }
@@ -0,0 +1,37 @@
+package gtiff
+
+import (
+	"os"
+)
+
+// File is an open tiff file. Its embedded Reader holds the parsed header and pages and reads
+// their data, typed with Data8, Data16 and Data32 or converted from any sample size with Data and
+// Float64. Like Reader, its methods are safe for concurrent use until Close is called.
+type File struct {
+	*Reader
+	f *os.File
+}
+
+// Open opens the named tiff file and parses its header and IFDs. Close must be called to close the file.
+func Open(name string) (*File, error) {
+	return OpenOptions(name, nil)
+}
+
+// OpenOptions opens the named tiff file like Open and reads pages with opt, which may be nil.
+func OpenOptions(name string, opt *ReadOptions) (*File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReaderOptions(f, opt)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &File{Reader: r, f: f}, nil
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	return f.f.Close()
+}
@@ -0,0 +1,134 @@
+package gtiff
+
+import (
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	for _, name := range []string{"test-images/cell8.tif", "test-images/cell16.tif", "test-images/cell32.tif"} {
+		f, err := Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tags, header, err := ReadTags(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Header() != header || f.NumPages() != 1 {
+			t.Errorf("%s: expected header %v and 1 page, got %v and %d pages", name, header, f.Header(), f.NumPages())
+		}
+
+		var expected interface{}
+		switch tags.BitsPerSample {
+		case 8:
+			expected, err = ReadData8(r, header, tags)
+		case 16:
+			expected, err = ReadData16(r, header, tags)
+		case 32:
+			expected, err = ReadData32(r, header, tags)
+		}
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := f.Data(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(data, expected) {
+			t.Errorf("%s: data changed", name)
+		}
+		floats, err := f.Float64(0)
+		if err != nil || len(floats) != int(tags.ImageWidth*tags.ImageLength) {
+			t.Errorf("%s: expected %d samples, got %d %v", name, tags.ImageWidth*tags.ImageLength, len(floats), err)
+		}
+		if err := f.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := Open("test-images/missing.tif"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gtiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name16 := filepath.Join(dir, "16.tif")
+	if err := WriteFile16(name16, []uint16{0, 65535, 32768, 1}, 2, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	name32 := filepath.Join(dir, "32.tif")
+	if err := WriteFile32(name32, []float32{0, -1.5, 2, math.Float32frombits(0xffffffff)}, 2, 2, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name         string
+		sampleFormat uint16
+		data         interface{}
+		floats       []float64
+	}{
+		{name16, 0, []uint16{0, 65535, 32768, 1}, []float64{0, 65535, 32768, 1}},
+		{name16, 1, []uint16{0, 65535, 32768, 1}, []float64{0, 65535, 32768, 1}},
+		{name16, 2, []int16{0, -1, -32768, 1}, []float64{0, -1, -32768, 1}},
+		{name32, 3, []float32{0, -1.5, 2, math.Float32frombits(0xffffffff)}, nil},
+		{name32, 0, []uint32{0, 0xbfc00000, 0x40000000, 0xffffffff}, []float64{0, 0xbfc00000, 0x40000000, 0xffffffff}},
+		{name32, 1, []uint32{0, 0xbfc00000, 0x40000000, 0xffffffff}, []float64{0, 0xbfc00000, 0x40000000, 0xffffffff}},
+		{name32, 2, []int32{0, -0x40400000, 0x40000000, -1}, []float64{0, -0x40400000, 0x40000000, -1}},
+	} {
+		f, err := Open(c.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.pages[0].SampleFormat = c.sampleFormat
+		data, err := f.Data(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.floats == nil {
+			// NaN does not compare equal, so compare bits
+			if got, ok := data.([]float32); !ok || !reflect.DeepEqual(float32Bytes(got), float32Bytes(c.data.([]float32))) {
+				t.Errorf("sample format %d: expected %v, got %v", c.sampleFormat, c.data, data)
+			}
+		} else {
+			if !reflect.DeepEqual(data, c.data) {
+				t.Errorf("sample format %d: expected %T %v, got %T %v", c.sampleFormat, c.data, c.data, data, data)
+			}
+			if got, err := f.Float64(0); err != nil || !reflect.DeepEqual(got, c.floats) {
+				t.Errorf("sample format %d: expected %v, got %v %v", c.sampleFormat, c.floats, got, err)
+			}
+		}
+		f.Close()
+	}
+
+	f, err := Open(name16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.pages[0].SampleFormat = 3
+	if _, err := f.Data(0); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected ErrUnsupportedType for 16 bit floating point, got %v", err)
+	}
+	if _, err := f.Float64(0); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("expected ErrUnsupportedType from Float64, got %v", err)
+	}
+	if _, err := f.Data(1); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package gtiff
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// read the small images in test-images stored compressed, with predictors, in tiles, big endian or as BigTIFF.
+// The libtiff-*.tif images were written by libtiff 4.5.0 rather than this package.
+func TestReadFormats(t *testing.T) {
+	expected16 := make([]uint16, 20*12)
+	expected32 := make([]float32, 20*12)
+	for y := 0; y < 12; y++ {
+		for x := 0; x < 20; x++ {
+			expected16[y*20+x] = uint16(x*300 + y*7)
+			expected32[y*20+x] = float32(x)/3 + float32(y)
+		}
+	}
+
+	for _, name := range []string{
+		"lzw16.tif", "deflate16.tif", "packbits16.tif", "bigtiff16.tif", "deflate32.tif",
+		"libtiff-deflate16-tiled.tif", "libtiff-packbits16.tif", "libtiff-lzw32-fp.tif", "libtiff-deflate32-tiled-fp.tif",
+	} {
+		r, err := os.Open("./test-images/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tags, header, err := ReadTags(r)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if tags.BitsPerSample == 16 {
+			data, err := ReadData16(r, header, tags)
+			if err != nil || !reflect.DeepEqual(data, expected16) {
+				t.Errorf("%s: data does not match, %v", name, err)
+			}
+		} else {
+			data, err := ReadData32(r, header, tags)
+			if err != nil || !reflect.DeepEqual(data, expected32) {
+				t.Errorf("%s: data does not match, %v", name, err)
+			}
+		}
+		r.Close()
+	}
+}
@@ -0,0 +1,75 @@
+//go:build go1.18
+// +build go1.18
+
+package gtiff
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// limits small enough that fuzzing never runs out of memory
+var fuzzLimits = Limits{MaxPixels: 1 << 20, MaxAlloc: 1 << 24, MaxIFDs: 16, MaxTagCount: 1 << 16}
+
+// FuzzRead checks that reading any file returns an error instead of panicking or allocating
+// beyond fuzzLimits. The corpus in testdata/fuzz/FuzzRead holds the files of malformedFiles.
+func FuzzRead(f *testing.F) {
+	names, err := filepath.Glob("test-images/cell*.tif")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, name := range names {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+	data := make([]float32, 40*30)
+	for i := range data {
+		data[i] = float32(i)
+	}
+	for _, opt := range []Options{
+		{BigTIFF: true, RowsPerStrip: 7},
+		{Compression: CompressionLZW, Predictor: PredictorFloatingPoint},
+		{Compression: CompressionDeflate, TileWidth: 16, TileLength: 16},
+		{Compression: CompressionPackBits, Predictor: PredictorHorizontal, Metadata: Tags{Software: "gtiff"}},
+	} {
+		w := &memFile{}
+		if err := NewEncoder(w, &opt).Encode32(data, 40, 30); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(w.buf)
+	}
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		r := bytes.NewReader(b)
+		tags, header, err := ReadTagsLimits(r, &fuzzLimits)
+		if err != nil {
+			return
+		}
+		ReadData8(r, header, tags)
+		ReadData16(r, header, tags)
+		ReadData32(r, header, tags)
+		ReadRegion16(r, header, tags, 1, 1, 2, 2)
+		if s, err := NewStripReader(r, header, tags); err == nil {
+			for s.Next() {
+			}
+		}
+
+		reader, err := NewReaderOptions(r, &ReadOptions{Concurrency: 2, Limits: &fuzzLimits, Mode: ModeLenient})
+		if err != nil {
+			return
+		}
+		for page := 0; page < reader.NumPages(); page++ {
+			reader.Data8(page)
+			reader.Data16(page)
+			reader.Data32(page)
+			if blocks, err := reader.NumBlocks(page); err == nil && blocks > 0 {
+				reader.Block(page, blocks-1)
+			}
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package gtiff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// a directory entry to be written along with its encoded value
+type entry struct {
+	tag   uint16
+	dtype uint16
+	count uint64
+	value []byte // encoded value(s), written inline if they fit in the value offset
+}
+
+// ifdBuilder collects directory entries and lays them out as a single IFD.
+type ifdBuilder struct {
+	byteOrder binary.ByteOrder
+	bigTIFF   bool
+	entries   []entry
+}
+
+// add an ascii (type 2) entry terminated by NUL, empty strings are skipped
+func (d *ifdBuilder) ascii(tag uint16, s string) {
+	if s == "" {
+		return
+	}
+	b := append([]byte(s), 0)
+	d.entries = append(d.entries, entry{tag, 2, uint64(len(b)), b})
+}
+
+// add a short (type 3) entry
+func (d *ifdBuilder) short(tag uint16, vals ...uint16) {
+	b := make([]byte, 2*len(vals))
+	for i, v := range vals {
+		d.byteOrder.PutUint16(b[2*i:], v)
+	}
+	d.entries = append(d.entries, entry{tag, 3, uint64(len(vals)), b})
+}
+
+// add a long (type 4) entry
+func (d *ifdBuilder) long(tag uint16, vals ...uint32) {
+	b := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		d.byteOrder.PutUint32(b[4*i:], v)
+	}
+	d.entries = append(d.entries, entry{tag, 4, uint64(len(vals)), b})
+}
+
+// add an entry of file offsets or byte counts, long (type 4) or long8 (type 16) for BigTIFF
+func (d *ifdBuilder) offsets(tag uint16, vals ...uint64) {
+	if !d.bigTIFF {
+		vals32 := make([]uint32, len(vals))
+		for i, v := range vals {
+			vals32[i] = uint32(v)
+		}
+		d.long(tag, vals32...)
+		return
+	}
+	b := make([]byte, 8*len(vals))
+	for i, v := range vals {
+		d.byteOrder.PutUint64(b[8*i:], v)
+	}
+	d.entries = append(d.entries, entry{tag, 16, uint64(len(vals)), b})
+}
+
+// add a rational (type 5) entry, vals are numerator, denominator pairs
+func (d *ifdBuilder) rational(tag uint16, vals ...uint32) {
+	b := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		d.byteOrder.PutUint32(b[4*i:], v)
+	}
+	d.entries = append(d.entries, entry{tag, 5, uint64(len(vals) / 2), b})
+}
+
+// add a raw field, converting its value to the byte order of the ifd. A classic tiff can't hold
+// more than 2^32-1 values in a tag or the 64 bit types of BigTIFF, which are written as their 32 bit
+// types if the values fit.
+func (d *ifdBuilder) field(f Field) error {
+	typeBytes, err := typeToBytes(f.Type)
+	if err != nil {
+		return err
+	}
+	if !d.bigTIFF && f.Count > math.MaxUint32 {
+		return fmt.Errorf("tag %d: %d values do not fit in a tiff file, write it as BigTIFF", f.Tag, f.Count)
+	}
+	if uint64(len(f.Value)) != f.Count*uint64(typeBytes) {
+		return fmt.Errorf("tag %d: expected %d bytes of value, got %d", f.Tag, f.Count*uint64(typeBytes), len(f.Value))
+	}
+	if !d.bigTIFF && f.Type >= 16 {
+		if f, err = d.field32(f); err != nil {
+			return err
+		}
+		typeBytes = 4
+	}
+
+	b := make([]byte, len(f.Value))
+	copy(b, f.Value)
+	if f.ByteOrder != nil && f.ByteOrder != d.byteOrder {
+		// rationals are swapped as 2 longs
+		size := int(typeBytes)
+		if f.Type == 5 || f.Type == 10 {
+			size = 4
+		}
+		swapBytes(b, size)
+	}
+	d.entries = append(d.entries, entry{f.Tag, f.Type, f.Count, b})
+	return nil
+}
+
+// f, of type long8, slong8 or ifd8, converted to long, slong or ifd in the byte order of the ifd
+func (d *ifdBuilder) field32(f Field) (Field, error) {
+	byteOrder := f.ByteOrder
+	if byteOrder == nil {
+		byteOrder = d.byteOrder
+	}
+	b := make([]byte, 4*f.Count)
+	for i := uint64(0); i < f.Count; i++ {
+		v := byteOrder.Uint64(f.Value[8*i:])
+		if (f.Type == 17 && int64(v) != int64(int32(v))) || (f.Type != 17 && v > math.MaxUint32) {
+			return f, fmt.Errorf("tag %d: value %d of type %d does not fit in a tiff file, write it as BigTIFF", f.Tag, v, f.Type)
+		}
+		d.byteOrder.PutUint32(b[4*i:], uint32(v))
+	}
+	dtype := uint16(4)
+	switch f.Type {
+	case 17:
+		dtype = 9
+	case 18:
+		dtype = 13
+	}
+	return Field{f.Tag, dtype, f.Count, b, d.byteOrder}, nil
+}
+
+// has reports if an entry for tag has been added
+func (d *ifdBuilder) has(tag uint16) bool {
+	for _, e := range d.entries {
+		if e.tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// sizes of the entry count, an entry and the value offset within an entry
+func (d *ifdBuilder) sizes() (countBytes, entryBytes, valueBytes uint64) {
+	if d.bigTIFF {
+		return 8, 20, 8
+	}
+	return 2, 12, 4
+}
+
+// size of the ifd and all out of line values in bytes
+func (d *ifdBuilder) size() uint64 {
+	countBytes, entryBytes, valueBytes := d.sizes()
+	n := countBytes + entryBytes*uint64(len(d.entries)) + valueBytes
+	for _, e := range d.entries {
+		if uint64(len(e.value)) > valueBytes {
+			n += wordAlign(uint64(len(e.value)))
+		}
+	}
+	return n
+}
+
+// encode lays out the ifd as it will appear at offset in the file: the number of entries,
+// the entries sorted by tag, a zero next ifd offset and then any values that don't fit in an entry.
+func (d *ifdBuilder) encode(offset uint64) []byte {
+	sort.SliceStable(d.entries, func(i, j int) bool { return d.entries[i].tag < d.entries[j].tag })
+
+	countBytes, entryBytes, valueBytes := d.sizes()
+	buf := make([]byte, d.size())
+	d.putUint(buf, countBytes, uint64(len(d.entries)))
+
+	// values area starts after the entries and next ifd offset
+	valueOffset := countBytes + entryBytes*uint64(len(d.entries)) + valueBytes
+	for i, e := range d.entries {
+		p := buf[countBytes+entryBytes*uint64(i):]
+		d.byteOrder.PutUint16(p[0:], e.tag)
+		d.byteOrder.PutUint16(p[2:], e.dtype)
+		d.putUint(p[4:], valueBytes, e.count)
+		p = p[4+valueBytes : 4+2*valueBytes]
+		if uint64(len(e.value)) <= valueBytes {
+			// values are left justified within the value offset field
+			copy(p, e.value)
+			continue
+		}
+		d.putUint(p, valueBytes, offset+valueOffset)
+		copy(buf[valueOffset:], e.value)
+		valueOffset += wordAlign(uint64(len(e.value)))
+	}
+
+	return buf
+}
+
+// write v to b as a size byte unsigned int
+func (d *ifdBuilder) putUint(b []byte, size uint64, v uint64) {
+	switch size {
+	case 2:
+		d.byteOrder.PutUint16(b, uint16(v))
+	case 4:
+		d.byteOrder.PutUint32(b, uint32(v))
+	default:
+		d.byteOrder.PutUint64(b, v)
+	}
+}
+
+// reverse the byte order of each size byte value in b
+func swapBytes(b []byte, size int) {
+	for i := 0; i+size <= len(b); i += size {
+		for j, k := i, i+size-1; j < k; j, k = j+1, k-1 {
+			b[j], b[k] = b[k], b[j]
+		}
+	}
+}
+
+// round n up to the next word (2 byte) boundary
+func wordAlign(n uint64) uint64 {
+	return n + n%2
+}
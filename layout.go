@@ -0,0 +1,210 @@
+package gtiff
+
+import (
+	"fmt"
+	"math"
+)
+
+// layout of the strips or tiles holding the pixel data of an image
+type layout struct {
+	width, length           uint32 // image size in pixels
+	blockWidth, blockLength uint32 // size of a strip or tile in pixels
+	across, down            uint32 // number of blocks per row and column of the image
+	bytesPerSample          uint32
+	offsets, counts         []uint64 // file offset and size in bytes of each block
+	tiled                   bool
+}
+
+// readLayout describes the strips or tiles of the image described by t and checks there
+// is an offset and byte count for each of them
+func readLayout(t Tags) (layout, error) {
+	l, err := newLayout(t)
+	if err != nil {
+		return l, err
+	}
+	if uint64(l.across)*uint64(l.down) != uint64(len(l.offsets)) {
+		return l, fmt.Errorf("%w: expected %d offsets, got %d", ErrInvalidLayout, uint64(l.across)*uint64(l.down), len(l.offsets))
+	}
+	if len(l.counts) != len(l.offsets) {
+		return l, fmt.Errorf("%w: expected %d byte counts, got %d", ErrInvalidLayout, len(l.offsets), len(l.counts))
+	}
+	return l, nil
+}
+
+// newLayout describes the size and arrangement of the strips or tiles of the image described by t
+func newLayout(t Tags) (layout, error) {
+	l := layout{width: t.ImageWidth, length: t.ImageLength}
+
+	switch t.BitsPerSample {
+	case 8, 16, 32:
+		l.bytesPerSample = uint32(t.BitsPerSample) / 8
+	default:
+		return l, fmt.Errorf("%w, got %d, expected 8, 16 or 32", ErrUnsupportedBitsPerSample, t.BitsPerSample)
+	}
+
+	if t.tiled() {
+		if t.TileWidth == 0 || t.TileLength == 0 {
+			return l, fmt.Errorf("%w: tile width and length must be set", ErrInvalidLayout)
+		}
+		l.tiled = true
+		l.blockWidth, l.blockLength = t.TileWidth, t.TileLength
+		l.offsets, l.counts = t.TileOffsets, t.TileByteCounts
+	} else {
+		l.blockWidth, l.blockLength = t.ImageWidth, t.RowsPerStrip
+		if l.blockLength == 0 || l.blockLength > t.ImageLength {
+			l.blockLength = t.ImageLength // default is the whole image in one strip
+		}
+		l.offsets, l.counts = t.StripOffsets, t.StripByteCounts
+	}
+	if l.blockWidth == 0 || l.blockLength == 0 {
+		return l, fmt.Errorf("%w: image has no pixels", ErrInvalidLayout)
+	}
+
+	// sizes in bytes of a row of the image and of a block must fit the uint32 arithmetic used on them
+	if uint64(l.width)*uint64(l.bytesPerSample) > math.MaxUint32 ||
+		uint64(l.blockWidth)*uint64(l.blockLength)*uint64(l.bytesPerSample) > math.MaxUint32 {
+		return l, fmt.Errorf("%w: rows, strips and tiles must be smaller than 4 GiB", ErrInvalidLayout)
+	}
+
+	l.across = uint32((uint64(l.width) + uint64(l.blockWidth) - 1) / uint64(l.blockWidth))
+	l.down = uint32((uint64(l.length) + uint64(l.blockLength) - 1) / uint64(l.blockLength))
+
+	return l, nil
+}
+
+// number of strips or tiles
+func (l layout) blocks() int {
+	return int(l.across * l.down)
+}
+
+// position and size of the part of the image covered by block i, clipped to the image
+func (l layout) rect(i int) (x, y, w, h uint32) {
+	x = uint32(i) % l.across * l.blockWidth
+	y = uint32(i) / l.across * l.blockLength
+	w, h = l.blockWidth, l.blockLength
+	if uint64(x)+uint64(w) > uint64(l.width) {
+		w = l.width - x
+	}
+	if uint64(y)+uint64(h) > uint64(l.length) {
+		h = l.length - y
+	}
+	return x, y, w, h
+}
+
+// uncompressed size of block i in bytes, tiles are always full size and strips are clipped
+func (l layout) blockBytes(i int) int {
+	if l.tiled {
+		return int(l.blockWidth * l.blockLength * l.bytesPerSample)
+	}
+	_, _, w, h := l.rect(i)
+	return int(w * h * l.bytesPerSample)
+}
+
+// err reading block i as a *BlockError
+func (l layout) blockError(i int, err error) error {
+	return &BlockError{i, l.offsets[i], truncated(err)}
+}
+
+// a rectangular part of an image
+type region struct {
+	x, y, width, length uint32
+}
+
+// indexes of the strips or tiles overlapping reg, reg must not be empty
+func (l layout) blocksIn(reg region) []int {
+	x0, x1, y0, y1 := l.blockRange(reg)
+	blocks := make([]int, 0, (x1-x0+1)*(y1-y0+1))
+	for by := y0; by <= y1; by++ {
+		for bx := x0; bx <= x1; bx++ {
+			blocks = append(blocks, int(by*l.across+bx))
+		}
+	}
+	return blocks
+}
+
+// first and last column and row of the strips or tiles overlapping reg, reg must not be empty
+func (l layout) blockRange(reg region) (x0, x1, y0, y1 uint32) {
+	x0, x1 = reg.x/l.blockWidth, (reg.x+reg.width-1)/l.blockWidth
+	y0, y1 = reg.y/l.blockLength, (reg.y+reg.length-1)/l.blockLength
+	return x0, x1, y0, y1
+}
+
+// part of the image covered by both block i and reg
+func (l layout) intersect(i int, reg region) region {
+	bx, by, bw, bh := l.rect(i)
+	x0, y0 := maxUint32(bx, reg.x), maxUint32(by, reg.y)
+	x1, y1 := minUint32(bx+bw, reg.x+reg.width), minUint32(by+bh, reg.y+reg.length)
+	if x1 <= x0 || y1 <= y0 {
+		return region{}
+	}
+	return region{x0, y0, x1 - x0, y1 - y0}
+}
+
+// copy the part of decoded block i inside reg into out, which holds the samples of reg
+func (l layout) copyToRegion(out, block []byte, i int, reg region) {
+	bx, by, _, _ := l.rect(i)
+	in := l.intersect(i, reg)
+	rowBytes := int(in.width * l.bytesPerSample)
+	blockRowBytes := int(l.blockWidth * l.bytesPerSample)
+	outRowBytes := int(reg.width * l.bytesPerSample)
+	for row := in.y; row < in.y+in.length; row++ {
+		src := int(row-by)*blockRowBytes + int((in.x-bx)*l.bytesPerSample)
+		dst := int(row-reg.y)*outRowBytes + int((in.x-reg.x)*l.bytesPerSample)
+		copy(out[dst:dst+rowBytes], block[src:src+rowBytes])
+	}
+}
+
+// fill the part of block i inside reg in out, which holds the samples of reg, with copies of sample
+func (l layout) fillRegion(out, sample []byte, i int, reg region) {
+	in := l.intersect(i, reg)
+	rowBytes := int(in.width * l.bytesPerSample)
+	outRowBytes := int(reg.width * l.bytesPerSample)
+	for row := in.y; row < in.y+in.length; row++ {
+		dst := int(row-reg.y)*outRowBytes + int((in.x-reg.x)*l.bytesPerSample)
+		for j := dst; j < dst+rowBytes; j += len(sample) {
+			copy(out[j:], sample)
+		}
+	}
+}
+
+// copy block i out of rows of the image starting at firstRow into block, padding tiles past the
+// edge of the image with zeros
+func (l layout) copyFromImage(block, rows []byte, firstRow uint32, i int) {
+	x, y, w, h := l.rect(i)
+	rowBytes := int(w * l.bytesPerSample)
+	blockRowBytes := int(l.blockWidth * l.bytesPerSample)
+	imgRowBytes := int(l.width * l.bytesPerSample)
+	for j := range block {
+		block[j] = 0
+	}
+	for row := 0; row < int(h); row++ {
+		start := (int(y-firstRow)+row)*imgRowBytes + int(x*l.bytesPerSample)
+		copy(block[row*blockRowBytes:row*blockRowBytes+rowBytes], rows[start:start+rowBytes])
+	}
+}
+
+// reports if the image is stored in tiles instead of strips
+func (t Tags) tiled() bool {
+	return t.TileWidth != 0 || t.TileLength != 0 || len(t.TileOffsets) != 0
+}
+
+func minUint32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxUint32(a, b uint32) uint32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,119 @@
+package gtiff
+
+import (
+	"fmt"
+)
+
+// Limits bound the resources used reading a file, so a malformed or hostile file returns an error
+// instead of exhausting memory. Zero fields use the value in DefaultLimits, set a field to its
+// maximum value to lift that limit.
+type Limits struct {
+	// MaxPixels is the largest width*length of an image.
+	MaxPixels uint64
+
+	// MaxAlloc is the largest single allocation in bytes made for a tag value or a strip or tile.
+	// It also limits the total size of the values in one IFD, and the decoded samples of an image
+	// together with the scratch space used to decode one of its strips or tiles. Reading with a
+	// Concurrency above 1 uses scratch space for one more strip or tile in each extra goroutine.
+	MaxAlloc uint64
+
+	// MaxIFDs is the largest number of IFDs (pages) in a file.
+	MaxIFDs int
+
+	// MaxTagCount is the largest number of values in one tag.
+	MaxTagCount uint64
+}
+
+// DefaultLimits are used by ReadTags and NewReader, and for any zero field of other Limits.
+var DefaultLimits = Limits{
+	MaxPixels:   1 << 28,
+	MaxAlloc:    1 << 30,
+	MaxIFDs:     1 << 16,
+	MaxTagCount: 1 << 24,
+}
+
+// l with zero fields replaced by DefaultLimits, a nil l is DefaultLimits
+func (l *Limits) orDefault() Limits {
+	d := DefaultLimits
+	if l == nil {
+		return d
+	}
+	if l.MaxPixels != 0 {
+		d.MaxPixels = l.MaxPixels
+	}
+	if l.MaxAlloc != 0 {
+		d.MaxAlloc = l.MaxAlloc
+	}
+	if l.MaxIFDs != 0 {
+		d.MaxIFDs = l.MaxIFDs
+	}
+	if l.MaxTagCount != 0 {
+		d.MaxTagCount = l.MaxTagCount
+	}
+	return d
+}
+
+// check the value of directory entry de can be read within the limits
+func (l Limits) checkEntry(de directoryEntry, typeBytes uint16) error {
+	if de.Count > l.MaxTagCount {
+		return fmt.Errorf("%w: tag %d has %d values, limit is %d", ErrLimit, de.Tag, de.Count, l.MaxTagCount)
+	}
+	if typeBytes != 0 && de.Count > l.MaxAlloc/uint64(typeBytes) {
+		return fmt.Errorf("%w: tag %d has %d values of %d bytes, limit is %d bytes", ErrLimit, de.Tag, de.Count, typeBytes, l.MaxAlloc)
+	}
+	return nil
+}
+
+// check the image described by t can be read within the limits
+func (l Limits) checkImage(t Tags) error {
+	pixels := uint64(t.ImageWidth) * uint64(t.ImageLength)
+	if pixels > l.MaxPixels {
+		return fmt.Errorf("%w: image of %dx%d has %d pixels, limit is %d", ErrLimit, t.ImageWidth, t.ImageLength, pixels, l.MaxPixels)
+	}
+	bytesPerSample := (uint64(t.BitsPerSample) + 7) / 8
+	if bytesPerSample == 0 {
+		bytesPerSample = 1
+	}
+	if pixels > l.MaxAlloc/bytesPerSample {
+		return fmt.Errorf("%w: image of %dx%d with %d bits per sample is larger than %d bytes", ErrLimit, t.ImageWidth, t.ImageLength, t.BitsPerSample, l.MaxAlloc)
+	}
+	if t.tiled() && uint64(t.TileWidth)*uint64(t.TileLength) > l.MaxAlloc/bytesPerSample {
+		return fmt.Errorf("%w: tile of %dx%d with %d bits per sample is larger than %d bytes", ErrLimit, t.TileWidth, t.TileLength, t.BitsPerSample, l.MaxAlloc)
+	}
+	var maxCount uint64
+	for _, counts := range [][]uint64{t.StripByteCounts, t.TileByteCounts} {
+		for i, n := range counts {
+			if n > l.MaxAlloc {
+				return fmt.Errorf("%w: block %d takes %d bytes, limit is %d", ErrLimit, i, n, l.MaxAlloc)
+			}
+			if n > maxCount {
+				maxCount = n
+			}
+		}
+	}
+
+	// a strip or tile is decoded into scratch space, reading compressed data into more
+	blockPixels := uint64(t.TileWidth) * uint64(t.TileLength)
+	if !t.tiled() {
+		rows := t.RowsPerStrip
+		if rows == 0 || rows > t.ImageLength {
+			rows = t.ImageLength
+		}
+		blockPixels = uint64(t.ImageWidth) * uint64(rows)
+	}
+	block := blockPixels * bytesPerSample
+	var src uint64
+	switch {
+	case t.Compression != 0 && t.Compression != CompressionNone:
+		src = maxCount
+		if src > 1024 && (src-1024)/2 > block {
+			src = 2*block + 1024 // the most read for a block, as in readBlock
+		}
+	case t.Predictor == 0 || t.Predictor == PredictorNone:
+		block = 0 // read straight from the file
+	}
+	if size := pixels * bytesPerSample; block > l.MaxAlloc-size || src > l.MaxAlloc-size-block {
+		return fmt.Errorf("%w: image of %dx%d with %d bits per sample takes %d bytes and %d bytes of scratch space, limit is %d", ErrLimit, t.ImageWidth, t.ImageLength, t.BitsPerSample, size, block+src, l.MaxAlloc)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package gtiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+// a little endian tiff of 8 bytes of pixel data followed by one IFD built by add
+func buildTiff(add func(d *ifdBuilder)) []byte {
+	d := &ifdBuilder{byteOrder: binary.LittleEndian}
+	add(d)
+	b := []byte{'I', 'I', 42, 0, 16, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8}
+	return append(b, d.encode(16)...)
+}
+
+// malformed files, each must be rejected without panicking or allocating more than the limits
+func malformedFiles() map[string][]byte {
+	image := func(d *ifdBuilder, width, length uint32) {
+		d.long(256, width)
+		d.long(257, length)
+		d.short(258, 8)
+	}
+	files := map[string][]byte{
+		"huge-image": buildTiff(func(d *ifdBuilder) {
+			image(d, 1<<20, 1<<20)
+			d.long(273, 8)
+			d.long(279, 8)
+		}),
+		"huge-strip-byte-count": buildTiff(func(d *ifdBuilder) {
+			image(d, 2, 4)
+			d.long(273, 8)
+			d.long(279, 0xffffffff)
+		}),
+		"huge-tag-count": buildTiff(func(d *ifdBuilder) {
+			image(d, 2, 4)
+			d.entries = append(d.entries, entry{273, 4, 1 << 30, []byte{8, 0, 0, 0}})
+			d.long(279, 8)
+		}),
+		"more-offsets-than-counts": buildTiff(func(d *ifdBuilder) {
+			image(d, 2, 4)
+			d.long(278, 2)
+			d.long(273, 8, 12)
+			d.long(279, 4)
+		}),
+		"huge-uncompressed-strips": buildTiff(func(d *ifdBuilder) {
+			d.long(256, 32768)
+			d.long(257, 32768)
+			d.short(258, 32)
+			d.long(273, 8, 8)
+			d.long(278, 16384)
+			d.long(279, 1<<31, 1<<31)
+		}),
+		"huge-deflate-strip": buildTiff(func(d *ifdBuilder) {
+			image(d, 32768, 32768)
+			d.short(259, CompressionDeflate)
+			d.long(273, 8)
+			d.long(279, 8)
+		}),
+		"small-deflate-strip": buildTiff(func(d *ifdBuilder) {
+			image(d, 8192, 8192)
+			d.short(259, CompressionDeflate)
+			d.long(273, 8)
+			d.long(279, 8)
+		}),
+		"huge-sparse-tiles": buildTiff(func(d *ifdBuilder) {
+			d.long(256, 1<<20)
+			d.long(257, 1<<10)
+			d.short(258, 32)
+			d.long(322, 1<<16)
+			d.long(323, 1<<10)
+			d.long(324, make([]uint32, 16)...)
+			d.long(325, make([]uint32, 16)...)
+			d.short(339, 3)
+		}),
+		"overlapping-uncompressed-strips": buildTiff(func(d *ifdBuilder) {
+			image(d, 8192, 8192)
+			d.long(273, 8, 8)
+			d.long(278, 4096)
+			d.long(279, 1<<25, 1<<25)
+		}),
+		"huge-tile": buildTiff(func(d *ifdBuilder) {
+			image(d, 2, 4)
+			d.long(322, 1<<16)
+			d.long(323, 1<<16)
+			d.long(324, 8)
+			d.long(325, 8)
+		}),
+	}
+
+	// next IFD offset points back to the same IFD
+	loop := buildTiff(func(d *ifdBuilder) {
+		image(d, 2, 4)
+		d.long(273, 8)
+		d.long(279, 8)
+	})
+	binary.LittleEndian.PutUint32(loop[16+2+5*12:], 16) // after the 5 entries
+	files["ifd-loop"] = loop
+
+	return files
+}
+
+func TestLimits(t *testing.T) {
+	limits := &Limits{MaxPixels: 1 << 20, MaxAlloc: 1 << 24, MaxIFDs: 16, MaxTagCount: 1 << 16}
+	for name, b := range malformedFiles() {
+		r := bytes.NewReader(b)
+		tags, header, err := ReadTagsLimits(r, limits)
+		if err == nil {
+			_, err = ReadData8(r, header, tags)
+		}
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		if _, err := NewReaderOptions(r, &ReadOptions{Limits: limits}); err == nil && name != "more-offsets-than-counts" {
+			t.Errorf("%s: expected error from NewReader", name)
+		}
+	}
+
+	// an image too large for its file is rejected before it can be allocated even with the default limits
+	small := malformedFiles()["small-deflate-strip"]
+	if _, _, err := ReadTags(bytes.NewReader(small)); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt reading %d byte file of a 64 MiB deflate image, got %v", len(small), err)
+	}
+	overlapping := malformedFiles()["overlapping-uncompressed-strips"]
+	if _, err := NewReaderOptions(bytes.NewReader(overlapping), &ReadOptions{Mode: ModeLenient}); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("expected ErrCorrupt from NewReader of %d byte file of a 64 MiB image, got %v", len(overlapping), err)
+	}
+
+	// a valid file is only rejected by limits it exceeds
+	valid := buildTiff(func(d *ifdBuilder) {
+		d.long(256, 2)
+		d.long(257, 4)
+		d.short(258, 8)
+		d.long(273, 8)
+		d.long(279, 8)
+	})
+	if _, _, err := ReadTagsLimits(bytes.NewReader(valid), &Limits{MaxPixels: 8}); err != nil {
+		t.Error(err)
+	}
+	if _, _, err := ReadTagsLimits(bytes.NewReader(valid), &Limits{MaxPixels: 7}); err == nil {
+		t.Error("expected error reading image larger than MaxPixels")
+	}
+	if _, _, err := ReadTagsLimits(bytes.NewReader(valid), &Limits{MaxTagCount: 1, MaxAlloc: 7}); err == nil {
+		t.Error("expected error reading values larger than MaxAlloc")
+	}
+}
+
+func TestDefaultLimits(t *testing.T) {
+	for name, b := range malformedFiles() {
+		for _, mode := range []Mode{ModeDefault, ModeStrict, ModeLenient} {
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			r := bytes.NewReader(b)
+			tags, header, err := ReadTagsOptions(r, &ReadOptions{Mode: mode})
+			if err == nil {
+				switch tags.BitsPerSample {
+				case 16:
+					_, err = ReadData16(r, header, tags)
+				case 32:
+					_, err = ReadData32(r, header, tags)
+				default:
+					_, err = ReadData8(r, header, tags)
+				}
+			}
+			runtime.ReadMemStats(&after)
+			if err == nil {
+				t.Errorf("%s, mode %d: expected error", name, mode)
+			}
+			if n := after.TotalAlloc - before.TotalAlloc; n > 1<<24 {
+				t.Errorf("%s, mode %d: %d byte file allocated %d bytes", name, mode, len(b), n)
+			}
+		}
+	}
+}
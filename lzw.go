@@ -0,0 +1,184 @@
+package gtiff
+
+import (
+	"fmt"
+)
+
+// tiff flavour of lzw per section 13 of the tiff 6.0 spec: codes are packed msb first,
+// start at 9 bits, grow to at most 12 bits and grow one code early.
+const (
+	lzwClear    = 256
+	lzwEOI      = 257
+	lzwFirst    = 258
+	lzwMinWidth = 9
+	lzwMaxWidth = 12
+	lzwMaxCode  = 1<<lzwMaxWidth - 1
+)
+
+// writes variable width codes msb first
+type lzwBitWriter struct {
+	out   []byte
+	bits  uint32
+	nBits uint
+}
+
+func (w *lzwBitWriter) write(code uint32, width uint) {
+	w.bits = w.bits<<width | code
+	w.nBits += width
+	for w.nBits >= 8 {
+		w.nBits -= 8
+		w.out = append(w.out, byte(w.bits>>w.nBits))
+	}
+}
+
+func (w *lzwBitWriter) flush() []byte {
+	if w.nBits > 0 {
+		w.out = append(w.out, byte(w.bits<<(8-w.nBits)))
+		w.nBits = 0
+	}
+	return w.out
+}
+
+// lzwEncode compresses src.
+func lzwEncode(src []byte) []byte {
+	w := lzwBitWriter{out: make([]byte, 0, len(src)/2+16)}
+	width := uint(lzwMinWidth)
+	w.write(lzwClear, width)
+	if len(src) == 0 {
+		w.write(lzwEOI, width)
+		return w.flush()
+	}
+
+	// string table keyed by prefix code and next byte
+	table := make(map[uint32]uint16, lzwMaxCode)
+	next := uint32(lzwFirst)
+
+	// add a table entry after emitting a code, clearing the table before it overflows
+	grow := func() {
+		next++
+		if next == lzwMaxCode-1 {
+			w.write(lzwClear, width)
+			for k := range table {
+				delete(table, k)
+			}
+			next = lzwFirst
+			width = lzwMinWidth
+		} else if next > 1<<width-1 {
+			width++
+		}
+	}
+
+	prefix := uint32(src[0])
+	for _, c := range src[1:] {
+		key := prefix<<8 | uint32(c)
+		if code, ok := table[key]; ok {
+			prefix = uint32(code)
+			continue
+		}
+		w.write(prefix, width)
+		table[key] = uint16(next)
+		grow()
+		prefix = uint32(c)
+	}
+	w.write(prefix, width)
+	grow()
+	w.write(lzwEOI, width)
+
+	return w.flush()
+}
+
+// lzwDecode decompresses src into dst and returns the number of bytes written.
+// Decoding stops at the end of information code, the end of src, or when dst is full.
+func lzwDecode(dst, src []byte) (int, error) {
+	var (
+		prefix [lzwMaxCode + 1]uint16
+		suffix [lzwMaxCode + 1]byte
+		first  [lzwMaxCode + 1]byte // first byte of each string
+		length [lzwMaxCode + 1]uint16
+	)
+	for i := 0; i < 256; i++ {
+		suffix[i] = byte(i)
+		first[i] = byte(i)
+		length[i] = 1
+	}
+
+	var (
+		bits  uint32
+		nBits uint
+		pos   int
+		n     int
+		width = uint(lzwMinWidth)
+		next  = uint32(lzwFirst)
+		prev  = -1
+	)
+	for n < len(dst) {
+		// read next code
+		for nBits < width {
+			if pos >= len(src) {
+				return n, fmt.Errorf("lzw: %w, missing end of information code", ErrCorrupt)
+			}
+			bits = bits<<8 | uint32(src[pos])
+			pos++
+			nBits += 8
+		}
+		nBits -= width
+		code := bits >> nBits & (1<<width - 1)
+
+		switch {
+		case code == lzwEOI:
+			return n, nil
+		case code == lzwClear:
+			width = lzwMinWidth
+			next = lzwFirst
+			prev = -1
+			continue
+		case prev == -1:
+			if code > 255 {
+				return n, fmt.Errorf("lzw: %w, invalid code after clear", ErrCorrupt)
+			}
+			dst[n] = byte(code)
+			n++
+			prev = int(code)
+			continue
+		case code > next || (code == next && next > lzwMaxCode):
+			return n, fmt.Errorf("lzw: %w, invalid code", ErrCorrupt)
+		}
+
+		// add new string of previous string + first byte of current string
+		if next <= lzwMaxCode {
+			c := first[code]
+			if code == next {
+				c = first[prev]
+			}
+			prefix[next] = uint16(prev)
+			suffix[next] = c
+			first[next] = first[prev]
+			length[next] = length[prev] + 1
+			next++
+			if next >= 1<<width-1 && width < lzwMaxWidth {
+				width++
+			}
+		}
+
+		// write string for code backwards from its last byte
+		l := int(length[code])
+		end := n + l
+		if end > len(dst) {
+			// truncate to space left in dst
+			for c := code; l > 0; l-- {
+				if n+l-1 < len(dst) {
+					dst[n+l-1] = suffix[c]
+				}
+				c = uint32(prefix[c])
+			}
+			return len(dst), nil
+		}
+		for c, i := code, end-1; i >= n; i-- {
+			dst[i] = suffix[c]
+			c = uint32(prefix[c])
+		}
+		n = end
+		prev = int(code)
+	}
+	return n, nil
+}
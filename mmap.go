@@ -0,0 +1,191 @@
+//go:build linux
+// +build linux
+
+package gtiff
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// MappedFile is a tiff file mapped read-only into memory. Its embedded Reader reads any page,
+// and uncompressed pages in native byte order can be viewed as slices of the mapping without copying.
+// Like Reader, its methods are safe for concurrent use until Close is called.
+type MappedFile struct {
+	*Reader
+	m *mapping
+}
+
+// mapping reads the memory of a mapped file as an io.ReaderAt. Reads after unmap return os.ErrClosed
+// instead of touching unmapped memory, and unmap waits for reads in progress.
+type mapping struct {
+	mu   sync.RWMutex
+	data []byte // nil once unmapped
+	size int64
+}
+
+func (m *mapping) ReadAt(p []byte, off int64) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.data == nil {
+		return 0, os.ErrClosed
+	}
+	if off < 0 {
+		return 0, errors.New("mmap: negative offset")
+	}
+	if off >= m.size {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Size of the mapped file, used to check offsets against the end of the file
+func (m *mapping) Size() int64 {
+	return m.size
+}
+
+// unmap the file once reads in progress finish
+func (m *mapping) unmap() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.data == nil {
+		return nil
+	}
+	err := syscall.Munmap(m.data)
+	m.data = nil
+	return err
+}
+
+// OpenMapped maps the named tiff file into memory and parses its header and IFDs.
+// Pixel data is only read from disk as it is used. Close must be called to unmap the file.
+func OpenMapped(name string) (*MappedFile, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // the mapping stays valid after the file is closed
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, fmt.Errorf("parse: %w, file is empty", ErrTruncated)
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("parse: file of %d bytes is too large to map", size)
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &mapping{data: data, size: size}
+	r, err := NewReader(m)
+	if err != nil {
+		m.unmap()
+		return nil, err
+	}
+	return &MappedFile{Reader: r, m: m}, nil
+}
+
+// Close unmaps the file, waiting for reads in progress. Reads afterwards return os.ErrClosed.
+// Slices returned by View8, View16 and View32 must not be used afterwards.
+func (m *MappedFile) Close() error {
+	return m.m.unmap()
+}
+
+// View8 returns the samples of an uncompressed 8 bit page as a slice of the mapping.
+// The slice is read-only, writing to it faults.
+func (m *MappedFile) View8(page int) ([]uint8, error) {
+	return m.view(page, 8)
+}
+
+// View16 returns the samples of an uncompressed 16 bit page in native byte order as a slice of the mapping.
+// The slice is read-only, writing to it faults.
+func (m *MappedFile) View16(page int) ([]uint16, error) {
+	b, err := m.view(page, 16)
+	if err != nil || len(b) == 0 {
+		return nil, err
+	}
+	var s []uint16
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&s))
+	h.Data = uintptr(unsafe.Pointer(&b[0]))
+	h.Len = len(b) / 2
+	h.Cap = len(b) / 2
+	return s, nil
+}
+
+// View32 returns the samples of an uncompressed 32 bit float page in native byte order as a slice of the mapping.
+// The slice is read-only, writing to it faults.
+func (m *MappedFile) View32(page int) ([]float32, error) {
+	b, err := m.view(page, 32)
+	if err != nil || len(b) == 0 {
+		return nil, err
+	}
+	var s []float32
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&s))
+	h.Data = uintptr(unsafe.Pointer(&b[0]))
+	h.Len = len(b) / 4
+	h.Cap = len(b) / 4
+	return s, nil
+}
+
+// bytes of the mapping holding the samples of page, which must be uncompressed, without a predictor,
+// in native byte order, stored in strips one after another and aligned for samples of bitsPerSample
+func (m *MappedFile) view(page int, bitsPerSample uint16) ([]byte, error) {
+	m.m.mu.RLock()
+	defer m.m.mu.RUnlock()
+	data := m.m.data
+	if data == nil {
+		return nil, os.ErrClosed
+	}
+	t, err := m.Tags(page)
+	if err != nil {
+		return nil, err
+	}
+	l, err := regionLayout(t, bitsPerSample, region{})
+	if err != nil {
+		return nil, err
+	}
+	if t.Compression != 0 && t.Compression != CompressionNone {
+		return nil, fmt.Errorf("read: page %d is compressed, got compression %d", page, t.Compression)
+	}
+	if t.Predictor != 0 && t.Predictor != PredictorNone {
+		return nil, fmt.Errorf("read: page %d uses predictor %d", page, t.Predictor)
+	}
+	if bitsPerSample > 8 && m.h.ByteOrder != nativeEndian {
+		return nil, fmt.Errorf("read: page %d is not in native byte order", page)
+	}
+	if l.tiled {
+		return nil, fmt.Errorf("read: page %d is tiled", page)
+	}
+
+	start := l.offsets[0]
+	next := start
+	for i := range l.offsets {
+		n := uint64(l.blockBytes(i))
+		if l.offsets[i] != next || l.counts[i] < n {
+			return nil, fmt.Errorf("read: strips of page %d are not stored one after another", page)
+		}
+		next += n
+	}
+	if next > uint64(len(data)) {
+		return nil, fmt.Errorf("read: strips of page %d end past the end of the file", page)
+	}
+	if start%uint64(l.bytesPerSample) != 0 {
+		return nil, fmt.Errorf("read: samples of page %d are not aligned", page)
+	}
+	return data[start:next:next], nil
+}
@@ -0,0 +1,102 @@
+//go:build linux
+// +build linux
+
+package gtiff
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestMappedFile(t *testing.T) {
+	const width, length = 40, 30
+	data := make([]uint16, width*length)
+	for i := range data {
+		data[i] = uint16(i * 7)
+	}
+
+	dir, err := ioutil.TempDir("", "gtiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name string, opt Options) string {
+		name = filepath.Join(dir, name)
+		f, err := os.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := NewEncoder(f, &opt).Encode16(data, width, length); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+
+	m, err := OpenMapped(write("lzw.tif", Options{ByteOrder: nativeEndian, Compression: CompressionLZW}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.View16(0); err == nil {
+		t.Error("expected error viewing compressed page")
+	}
+	if got, err := m.Data16(0); err != nil || !reflect.DeepEqual(data, got) {
+		t.Errorf("compressed page does not match data written, %v", err)
+	}
+	m.Close()
+
+	m, err = OpenMapped(write("none.tif", Options{ByteOrder: nativeEndian, RowsPerStrip: 7}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	got, err := m.View16(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data, got) {
+		t.Error("mapped data does not match data written")
+	}
+	if _, err := m.View8(0); err == nil {
+		t.Error("expected error viewing 16 bit page as 8 bit")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.View16(0); err == nil {
+		t.Error("expected error viewing closed file")
+	}
+	if _, err := m.Data16(0); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected os.ErrClosed reading closed file, got %v", err)
+	}
+	if _, err := m.Block(0, 0); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("expected os.ErrClosed reading block of closed file, got %v", err)
+	}
+
+	// reads in progress finish or fail cleanly when the file is closed
+	m, err = OpenMapped(filepath.Join(dir, "lzw.tif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if got, err := m.Data16(0); err != nil && !errors.Is(err, os.ErrClosed) || err == nil && !reflect.DeepEqual(data, got) {
+					t.Errorf("expected data or os.ErrClosed, got %v", err)
+					return
+				}
+			}
+		}()
+	}
+	m.Close()
+	wg.Wait()
+}
@@ -0,0 +1,187 @@
+package gtiff
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// parser holds the state and options of reading the IFDs of one file. It checks the offsets followed
+// from the header through the IFDs, rejecting offsets outside the file or pointing to an IFD already read.
+type parser struct {
+	h       Header
+	size    uint64 // size of the file, 0 if unknown
+	visited map[uint64]bool
+	lim     Limits
+	mode    Mode
+	warn    func(error)
+}
+
+// newParser returns a parser of the file with header h and size bytes, using the Limits, Mode and Warn of opt
+func newParser(h Header, size uint64, opt *ReadOptions) *parser {
+	p := &parser{h: h, size: size, visited: make(map[uint64]bool), lim: DefaultLimits}
+	if opt != nil {
+		p.lim = opt.Limits.orDefault()
+		p.mode = opt.Mode
+		p.warn = opt.Warn
+	}
+	return p
+}
+
+// problem handles err, a problem that does not stop the file being read. It is returned in ModeStrict,
+// otherwise it is passed to warn and nil is returned.
+func (p *parser) problem(err error) error {
+	if p.mode == ModeStrict {
+		return err
+	}
+	if p.warn != nil {
+		p.warn(err)
+	}
+	return nil
+}
+
+// check the IFD at offset can be read next
+func (p *parser) next(offset uint64) error {
+	headerBytes, minIFDBytes := uint64(8), uint64(2+4)
+	if p.h.BigTIFF {
+		headerBytes, minIFDBytes = 16, 8+8
+	}
+	switch {
+	case offset < headerBytes:
+		return &IFDError{offset, "overlaps the header"}
+	case p.size != 0 && (offset > p.size || p.size-offset < minIFDBytes):
+		return &IFDError{offset, fmt.Sprintf("is past the end of the file of %d bytes", p.size)}
+	case p.visited[offset]:
+		return &IFDError{offset, "was already read, the IFDs form a loop"}
+	}
+	if offset%2 != 0 {
+		err := &IFDError{offset, "is not word aligned"}
+		if p.mode != ModeLenient {
+			return err
+		}
+		p.problem(err)
+	}
+	p.visited[offset] = true
+	return nil
+}
+
+// checkIFD checks the IFD at offset read into t has the tags required for an image and that its
+// byte counts fit the file and the image. Missing byte counts of uncompressed images are filled in,
+// and in ModeLenient byte counts are corrected where they can be. Outside ModeLenient uncompressed
+// strips or tiles that can't fit in the file are an error, so hostile files can't force large allocations.
+func (p *parser) checkIFD(offset uint64, t *Tags, seen map[uint16]bool) error {
+	required := []uint16{256, 257, 262, 273, 279}
+	if t.tiled() {
+		required = []uint16{256, 257, 262, 322, 323, 324, 325}
+	}
+	for _, tag := range required {
+		if !seen[tag] {
+			err := &TagError{tag, 0, fmt.Errorf("%w, required tag is missing from IFD at %d", ErrSpecViolation, offset)}
+			if err := p.problem(err); err != nil {
+				return err
+			}
+		}
+	}
+
+	l, err := newLayout(*t)
+	if err != nil || len(l.offsets) > l.blocks() {
+		return nil // reported when the image is read
+	}
+	uncompressed := t.Compression == 0 || t.Compression == CompressionNone
+	if len(l.counts) == 0 && uncompressed {
+		// some old writers leave out the byte counts of uncompressed images, which follow from their size
+		l.counts = make([]uint64, len(l.offsets))
+		for i := range l.counts {
+			l.counts[i] = uint64(l.blockBytes(i))
+		}
+		if l.tiled {
+			t.TileByteCounts = l.counts
+		} else {
+			t.StripByteCounts = l.counts
+		}
+	}
+	if len(l.counts) != len(l.offsets) {
+		return nil
+	}
+	// in every mode the data must fit the file, so a small file can't make a large image be allocated
+	var stored, decoded uint64
+	spanning := -1
+	for i, start := range l.offsets {
+		count, want := l.counts[i], uint64(l.blockBytes(i))
+		if !l.sparse(i) {
+			if stored += count; stored < count {
+				stored = math.MaxUint64
+			}
+			decoded += want
+			end := count
+			if uncompressed {
+				end = want
+			}
+			if p.size != 0 && start < p.size && end > p.size-start {
+				// a file cut short ends part way through one block, more would overlap
+				if spanning >= 0 {
+					return &BlockError{i, start, fmt.Errorf("%w, block %d also runs past the end of the file of %d bytes", ErrCorrupt, spanning, p.size)}
+				}
+				spanning = i
+			}
+		}
+		var err error
+		switch {
+		case l.sparse(i):
+			// an empty block, read as nodata
+		case p.size != 0 && (start > p.size || count > p.size-start):
+			// the file was cut short, the block is read as far as it goes
+			err = fmt.Errorf("%w, %d bytes past the end of the file of %d bytes", ErrTruncated, count, p.size)
+			if p.mode == ModeLenient && start < p.size {
+				l.counts[i] = p.size - start
+			}
+		case uncompressed && count < want:
+			err = fmt.Errorf("%w, byte count %d is less than the %d bytes of the block", ErrSpecViolation, count, want)
+			if p.mode == ModeLenient && (p.size == 0 || want <= p.size-start) {
+				l.counts[i] = want
+			}
+		case uncompressed && count > want:
+			err = fmt.Errorf("%w, byte count %d is more than the %d bytes of the block", ErrSpecViolation, count, want)
+		}
+		if err != nil {
+			if err := p.problem(&BlockError{i, start, err}); err != nil {
+				return err
+			}
+		}
+	}
+	if min := minCompressed(t.Compression, decoded); !uncompressed && stored < min {
+		return fmt.Errorf("parse: IFD at offset %d: %w, %d bytes of strips or tiles can't decode to %d bytes", offset, ErrCorrupt, stored, decoded)
+	}
+	return nil
+}
+
+// size of the file read through r, or 0 if it can't be found
+func seekerSize(r io.Seeker) uint64 {
+	pos, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0
+	}
+	if _, err := r.Seek(pos, io.SeekStart); err != nil {
+		return 0
+	}
+	return uint64(end)
+}
+
+// size of the file read through r if it reports one, as *bytes.Reader, *io.SectionReader
+// and *os.File do, or 0
+func readerAtSize(r io.ReaderAt) uint64 {
+	switch r := r.(type) {
+	case interface{ Size() int64 }:
+		return uint64(r.Size())
+	case *os.File:
+		if info, err := r.Stat(); err == nil && info.Mode().IsRegular() {
+			return uint64(info.Size())
+		}
+	}
+	return 0
+}
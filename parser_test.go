@@ -0,0 +1,132 @@
+package gtiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestIFDChain(t *testing.T) {
+	file := buildTiff(func(d *ifdBuilder) {
+		d.long(256, 2)
+		d.long(257, 4)
+		d.short(258, 8)
+		d.long(273, 8)
+		d.long(279, 8)
+	})
+	const first, nextAt = 16, 16 + 2 + 5*12 // the first IFD and its next IFD offset
+
+	// a second IFD copied from the first, pointing back to it
+	second := uint64(len(file))
+	ifd := append([]byte(nil), file[first:]...)
+	binary.LittleEndian.PutUint32(ifd[nextAt-first:], first)
+	loop := append(append([]byte(nil), file...), ifd...)
+	binary.LittleEndian.PutUint32(loop[nextAt:], uint32(second))
+
+	tests := []struct {
+		name   string
+		file   []byte
+		offset uint64
+	}{
+		{"loop", loop, first},
+		{"odd", withNextIFD(file, nextAt, 101), 101},
+		{"past end", withNextIFD(file, nextAt, 4000), 4000},
+		{"in header", withNextIFD(file, 4, 2), 2},
+	}
+	for _, test := range tests {
+		_, _, err := ReadTags(bytes.NewReader(test.file))
+		var ifdErr *IFDError
+		if !errors.As(err, &ifdErr) {
+			t.Errorf("%s: expected IFDError, got %v", test.name, err)
+			continue
+		}
+		if ifdErr.Offset != test.offset {
+			t.Errorf("%s: expected offset %d, got %d", test.name, test.offset, ifdErr.Offset)
+		}
+		if _, err := NewReader(bytes.NewReader(test.file)); !errors.As(err, &ifdErr) {
+			t.Errorf("%s: expected IFDError from NewReader, got %v", test.name, err)
+		}
+	}
+}
+
+// copy of file with the IFD offset at pos set to offset
+func withNextIFD(file []byte, pos int, offset uint32) []byte {
+	b := append([]byte(nil), file...)
+	binary.LittleEndian.PutUint32(b[pos:], offset)
+	return b
+}
+
+func TestModes(t *testing.T) {
+	image := func(d *ifdBuilder, count uint32) {
+		d.long(256, 2)
+		d.long(257, 4)
+		d.short(258, 8)
+		d.short(262, 1)
+		d.long(273, 8)
+		d.long(279, count)
+	}
+	valid := buildTiff(func(d *ifdBuilder) { image(d, 8) })
+
+	// entries for tags 256 and 257 swapped
+	unsorted := append([]byte(nil), valid...)
+	copy(unsorted[18:30], valid[30:42])
+	copy(unsorted[30:42], valid[18:30])
+
+	// ifd at an odd offset
+	d := &ifdBuilder{byteOrder: binary.LittleEndian}
+	image(d, 8)
+	odd := append([]byte{'I', 'I', 42, 0, 17, 0, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8}, d.encode(17)...)
+	binary.LittleEndian.PutUint32(odd[17+2+4*12+8:], 9) // value of the StripOffsets entry
+
+	tests := []struct {
+		name     string
+		file     []byte
+		warnings int  // in ModeLenient, ModeStrict fails if there are any
+		fail     bool // in ModeDefault
+	}{
+		{"valid", valid, 0, false},
+		{"unsorted", unsorted, 1, false},
+		{"short byte count", buildTiff(func(d *ifdBuilder) { image(d, 4) }), 1, true},
+		{"byte count past end", buildTiff(func(d *ifdBuilder) { image(d, 1000) }), 1, false},
+		{"missing photometric", buildTiff(func(d *ifdBuilder) {
+			image(d, 8)
+			d.entries = append(d.entries[:3], d.entries[4:]...)
+		}), 1, false},
+		{"odd ifd offset", odd, 1, true},
+	}
+	for _, test := range tests {
+		for _, mode := range []Mode{ModeDefault, ModeStrict, ModeLenient} {
+			var warnings []error
+			opt := &ReadOptions{Mode: mode, Warn: func(err error) { warnings = append(warnings, err) }}
+			r := bytes.NewReader(test.file)
+			tags, header, err := ReadTagsOptions(r, opt)
+			if err == nil {
+				_, err = ReadData8(r, header, tags)
+			}
+
+			wantErr := (mode == ModeStrict && test.warnings > 0) || (mode == ModeDefault && test.fail)
+			if (err != nil) != wantErr {
+				t.Errorf("%s in mode %d: expected error %v, got %v", test.name, mode, wantErr, err)
+			}
+			if mode == ModeLenient && len(warnings) != test.warnings {
+				t.Errorf("%s in mode %d: expected %d warnings, got %v", test.name, mode, test.warnings, warnings)
+			}
+			if _, err := NewReaderOptions(bytes.NewReader(test.file), opt); mode == ModeStrict && (err == nil) == wantErr {
+				t.Errorf("%s: expected error %v from NewReader in strict mode, got %v", test.name, wantErr, err)
+			}
+		}
+	}
+
+	// strict mode rejects the odd value offset of a test image
+	f, err := os.Open("./test-images/cell8.tif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var tagErr *TagError
+	if _, _, err := ReadTagsOptions(f, &ReadOptions{Mode: ModeStrict}); !errors.Is(err, ErrSpecViolation) || !errors.As(err, &tagErr) || tagErr.Tag != 273 {
+		t.Errorf("expected ErrSpecViolation for tag 273, got %v", err)
+	}
+}
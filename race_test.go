@@ -0,0 +1,7 @@
+//go:build race
+// +build race
+
+package gtiff
+
+// the race detector randomly drops items from sync.Pool, so reads allocate
+const raceEnabled = true
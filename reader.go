@@ -3,14 +3,21 @@ package gtiff
 
 import (
 	"encoding/binary"
-	"errors"
 	"fmt"
 	"io"
-	"os"
+	"strings"
 )
 
-// structure of a Directory Entry
+// structure of a Directory Entry, count and offset are widened to 64 bits for BigTIFF
 type directoryEntry struct {
+	Tag         uint16 // tag id number
+	DType       uint16 // type of value
+	Count       uint64 // number of values
+	ValueOffset uint64 // offset to value
+}
+
+// structure of a Directory Entry in a tiff file
+type directoryEntry32 struct {
 	Tag         uint16 // tag id number
 	DType       uint16 // type of value
 	Count       uint32 // number of values
@@ -27,7 +34,7 @@ func ReadHeader(r io.Reader) (Header, error) {
 	// read byte order
 	err := binary.Read(r, binary.BigEndian, &byteOrder)
 	if err != nil {
-		return header, err
+		return header, truncated(err)
 	}
 
 	// parse byte order
@@ -37,29 +44,63 @@ func ReadHeader(r io.Reader) (Header, error) {
 	case 0X4D4D:
 		header.ByteOrder = binary.BigEndian
 	default:
-		return header, errors.New("parse: invalid byte order")
+		return header, fmt.Errorf("parse: %w, invalid byte order %#04x", ErrInvalidHeader, byteOrder)
 	}
 
 	// read tiff identifier order
 	err = binary.Read(r, header.ByteOrder, &header.TiffIdentifier)
 	if err != nil {
-		return header, err
-	}
-	if header.TiffIdentifier != 42 {
-		return header, fmt.Errorf("parse: invalid tiff identifier, expected: 42, got: %d", header.TiffIdentifier)
+		return header, truncated(err)
 	}
 
-	// read offset to first IFD
-	err = binary.Read(r, header.ByteOrder, &header.IFDOffset)
-	if err != nil {
-		return header, err
+	switch header.TiffIdentifier {
+	case 42:
+		// read offset to first IFD
+		var ifdOffset uint32
+		err = binary.Read(r, header.ByteOrder, &ifdOffset)
+		if err != nil {
+			return header, truncated(err)
+		}
+		header.IFDOffset = uint64(ifdOffset)
+	case 43:
+		// read BigTIFF size of offsets, reserved 0, and offset to first IFD
+		var big struct {
+			OffsetSize uint16
+			Reserved   uint16
+			IFDOffset  uint64
+		}
+		err = binary.Read(r, header.ByteOrder, &big)
+		if err != nil {
+			return header, truncated(err)
+		}
+		if big.OffsetSize != 8 {
+			return header, fmt.Errorf("parse: %w, BigTIFF offset size expected: 8, got: %d", ErrInvalidHeader, big.OffsetSize)
+		}
+		header.BigTIFF = true
+		header.IFDOffset = big.IFDOffset
+	default:
+		return header, fmt.Errorf("parse: %w, tiff identifier expected: 42 or 43, got: %d", ErrInvalidHeader, header.TiffIdentifier)
 	}
 
 	return header, nil
 }
 
-// ReadTags reads all tags in the tiff file and records the values of supported tags.
+// ReadTags reads the tags of the first page (IFD) of the tiff file and records the values of supported tags.
+// The IFDs of any other pages are parsed and checked but their tags are not returned, NewReader reads them.
+// The file must be within DefaultLimits.
 func ReadTags(r io.ReadSeeker) (Tags, Header, error) {
+	return ReadTagsLimits(r, nil)
+}
+
+// ReadTagsLimits reads the tags of the first page of the tiff file like ReadTags, returning an error if the file
+// exceeds limits. A nil limits uses DefaultLimits.
+func ReadTagsLimits(r io.ReadSeeker, limits *Limits) (Tags, Header, error) {
+	return ReadTagsOptions(r, &ReadOptions{Limits: limits})
+}
+
+// ReadTagsOptions reads the tags of the first page of the tiff file like ReadTags, using the Limits, Mode and Warn of opt.
+// A nil opt uses the defaults.
+func ReadTagsOptions(r io.ReadSeeker, opt *ReadOptions) (Tags, Header, error) {
 	var tags Tags
 
 	header, err := ReadHeader(r)
@@ -69,147 +110,312 @@ func ReadTags(r io.ReadSeeker) (Tags, Header, error) {
 
 	// offset to next IFD
 	nextIFD := header.IFDOffset
-	if _, err = r.Seek(int64(nextIFD), 0); err != nil {
-		return tags, header, err
+
+	p := newParser(header, seekerSize(r), opt)
+	for ifds := 0; nextIFD != 0; ifds++ {
+		if ifds == p.lim.MaxIFDs {
+			return tags, header, fmt.Errorf("%w: more than %d IFDs", ErrLimit, p.lim.MaxIFDs)
+		}
+		if err := p.next(nextIFD); err != nil {
+			return tags, header, err
+		}
+		// each page is read into its own Tags, only the first is returned
+		var page Tags
+		if nextIFD, err = readIFD(r, nextIFD, &page, p); err != nil {
+			return tags, header, err
+		}
+		if ifds == 0 {
+			tags = page
+		}
 	}
 
-	for nextIFD != 0 {
-		// number of directory entries
-		var numDE uint16
-		err = binary.Read(r, header.ByteOrder, &numDE)
+	return tags, header, nil
+}
+
+// read the IFD at offset into tags and return the offset of the next IFD, the values
+// of tags and the image they describe must be within the limits of p
+func readIFD(r io.ReadSeeker, offset uint64, tags *Tags, p *parser) (uint64, error) {
+	h, lim := p.h, p.lim
+	if _, err := r.Seek(int64(offset), 0); err != nil {
+		return 0, err
+	}
+
+	// number of directory entries
+	numDE, err := readIFDValue(r, h, 2)
+	if err != nil {
+		return 0, truncated(err)
+	}
+
+	// for each data directory
+	var nextDir int64
+	var valueBytesRead uint64 // entries can share a value, so their total is limited too
+	seen := make(map[uint16]bool)
+	var prevTag uint16
+	for i := uint64(0); i < numDE; i++ {
+		// read static parts of directory entry
+		de, err := readDirectoryEntry(r, h)
 		if err != nil {
-			return tags, header, err
+			return 0, truncated(err)
 		}
 
-		// for each data directory
-		var nextDir int64
-		for i := uint16(0); i < numDE; i++ {
-			// read static parts of directory entry
-			var de directoryEntry
-			err = binary.Read(r, header.ByteOrder, &de)
-			if err != nil {
-				return tags, header, err
+		// entries must be sorted by tag
+		switch {
+		case seen[de.Tag]:
+			err = fmt.Errorf("%w, tag appears more than once", ErrSpecViolation)
+		case i > 0 && de.Tag < prevTag:
+			err = fmt.Errorf("%w, tag follows tag %d", ErrSpecViolation, prevTag)
+		}
+		if err != nil {
+			if err := p.problem(&TagError{de.Tag, de.ValueOffset, err}); err != nil {
+				return 0, err
 			}
+		}
+		seen[de.Tag] = true
+		prevTag = de.Tag
 
-			// data type * number of values in bytes
-			typeBytes16, _ := typeToBytes(de.DType)
-			typeBytes := uint32(typeBytes16)
-			typeBytes *= de.Count // bytes * number of values
+		// data type * number of values in bytes
+		typeBytes16, _ := typeToBytes(de.DType)
+		if err := lim.checkEntry(de, typeBytes16); err != nil {
+			return 0, err
+		}
+		typeBytes := uint64(typeBytes16)
+		typeBytes *= de.Count // bytes * number of values
+		if valueBytesRead += typeBytes; valueBytesRead > lim.MaxAlloc {
+			return 0, fmt.Errorf("%w: values of IFD at %d take more than %d bytes", ErrLimit, offset, lim.MaxAlloc)
+		}
 
-			// if <= 4 bytes read value, else follow pointer to value
-			if typeBytes <= 4 {
-				// set directory entry value offset to current location in file
-				offset, _ := r.Seek(0, io.SeekCurrent) // get current position in file
-				de.ValueOffset = uint32(offset) - 4    // where we are now minus size of value offset (32bits=4bytes)
+		// if <= 4 bytes (8 for BigTIFF) read value, else follow pointer to value
+		valueBytes := uint64(4)
+		if h.BigTIFF {
+			valueBytes = 8
+		}
+		if typeBytes <= valueBytes {
+			// set directory entry value offset to current location in file
+			offset, _ := r.Seek(0, io.SeekCurrent)       // get current position in file
+			de.ValueOffset = uint64(offset) - valueBytes // where we are now minus size of value offset
+		} else if de.ValueOffset%2 != 0 {
+			err := fmt.Errorf("%w, value is not word aligned", ErrSpecViolation)
+			if err := p.problem(&TagError{de.Tag, de.ValueOffset, err}); err != nil {
+				return 0, err
 			}
+		}
 
-			nextDir, _ = r.Seek(0, io.SeekCurrent) // get current position in file
-
-			// if tag is supported then get the value(s), otherwise skip
-			switch de.Tag {
-			case 256:
-				err = getTagValue16or32(r, &tags.ImageWidth, header.ByteOrder, de)
-			case 257:
-				err = getTagValue16or32(r, &tags.ImageLength, header.ByteOrder, de)
-			case 258:
-				err = getTagValue16(r, &tags.BitsPerSample, header.ByteOrder, de)
-			case 259:
-				err = getTagValue16(r, &tags.Compression, header.ByteOrder, de)
-			case 262:
-				err = getTagValue16(r, &tags.PhotometricInterpretation, header.ByteOrder, de)
-			case 273:
-				err = getMultiTagValues16or32(r, &tags.StripOffsets, header.ByteOrder, de)
-			case 278:
-				err = getTagValue16or32(r, &tags.RowsPerStrip, header.ByteOrder, de)
-			case 279:
-				err = getMultiTagValues16or32(r, &tags.StripByteCounts, header.ByteOrder, de)
-			case 282:
-				err = getMultiTagValues16or32(r, &tags.XResolution, header.ByteOrder, de)
-			case 283:
-				err = getMultiTagValues16or32(r, &tags.YResolution, header.ByteOrder, de)
-			case 296:
-				err = getTagValue16(r, &tags.ResolutionUnit, header.ByteOrder, de)
-			default:
+		nextDir, _ = r.Seek(0, io.SeekCurrent) // get current position in file
+
+		// if tag is supported then get the value(s), otherwise skip
+		switch de.Tag {
+		case 256:
+			err = getTagValue16or32(r, &tags.ImageWidth, h.ByteOrder, de)
+		case 257:
+			err = getTagValue16or32(r, &tags.ImageLength, h.ByteOrder, de)
+		case 258:
+			err = getTagValue16(r, &tags.BitsPerSample, h.ByteOrder, de)
+		case 259:
+			err = getTagValue16(r, &tags.Compression, h.ByteOrder, de)
+		case 262:
+			err = getTagValue16(r, &tags.PhotometricInterpretation, h.ByteOrder, de)
+		case 273:
+			err = getMultiTagValues(r, &tags.StripOffsets, h.ByteOrder, de)
+		case 278:
+			err = getTagValue16or32(r, &tags.RowsPerStrip, h.ByteOrder, de)
+		case 279:
+			err = getMultiTagValues(r, &tags.StripByteCounts, h.ByteOrder, de)
+		case 282:
+			err = getRational(r, &tags.XResolution, h.ByteOrder, de)
+		case 283:
+			err = getRational(r, &tags.YResolution, h.ByteOrder, de)
+		case 296:
+			err = getTagValue16(r, &tags.ResolutionUnit, h.ByteOrder, de)
+		case 317:
+			err = getTagValue16(r, &tags.Predictor, h.ByteOrder, de)
+		case 322:
+			err = getTagValue16or32(r, &tags.TileWidth, h.ByteOrder, de)
+		case 323:
+			err = getTagValue16or32(r, &tags.TileLength, h.ByteOrder, de)
+		case 324:
+			err = getMultiTagValues(r, &tags.TileOffsets, h.ByteOrder, de)
+		case 325:
+			err = getMultiTagValues(r, &tags.TileByteCounts, h.ByteOrder, de)
+		case 339:
+			err = getTagValue16(r, &tags.SampleFormat, h.ByteOrder, de)
+		case 269:
+			err = getASCII(r, &tags.DocumentName, de)
+		case 270:
+			err = getASCII(r, &tags.ImageDescription, de)
+		case 271:
+			err = getASCII(r, &tags.Make, de)
+		case 272:
+			err = getASCII(r, &tags.Model, de)
+		case 285:
+			err = getASCII(r, &tags.PageName, de)
+		case 305:
+			err = getASCII(r, &tags.Software, de)
+		case 306:
+			err = getASCII(r, &tags.DateTime, de)
+		case 315:
+			err = getASCII(r, &tags.Artist, de)
+		case 316:
+			err = getASCII(r, &tags.HostComputer, de)
+		case 33432:
+			err = getASCII(r, &tags.Copyright, de)
+		default:
+			if _, err := typeToBytes(de.DType); err != nil {
+				// size of value unknown, can't be kept
+				if err := p.problem(&TagError{de.Tag, de.ValueOffset, err}); err != nil {
+					return 0, err
+				}
 				continue
 			}
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "warning: unable to read value for tag %d -- %s\n", de.Tag, err)
+			err = getField(r, &tags.Extra, h.ByteOrder, de)
+		}
+		if err != nil {
+			// the image can't be read without a structural tag, other tags are skipped
+			tagErr := &TagError{de.Tag, de.ValueOffset, truncated(err)}
+			if structuralTag(de.Tag) {
+				return 0, tagErr
 			}
-
-			// seek to next dir
-			if _, err = r.Seek(nextDir, 0); err != nil {
-				return tags, header, err
+			if err := p.problem(tagErr); err != nil {
+				return 0, err
 			}
 		}
 
-		// get offset to next ifd
-		err = binary.Read(r, header.ByteOrder, &nextIFD)
-		if err != nil {
-			return tags, header, err
+		// seek to next dir
+		if _, err = r.Seek(nextDir, 0); err != nil {
+			return 0, err
 		}
 	}
 
-	return tags, header, nil
+	if err := lim.checkImage(*tags); err != nil {
+		return 0, err
+	}
+	if err := p.checkIFD(offset, tags, seen); err != nil {
+		return 0, err
+	}
+
+	// get offset to next ifd
+	next, err := readIFDValue(r, h, 4)
+	return next, truncated(err)
+}
+
+// reports if tag describes the layout or encoding of the pixel data
+func structuralTag(tag uint16) bool {
+	switch tag {
+	case 256, 257, 258, 259, 273, 278, 279, 317, 322, 323, 324, 325, 339:
+		return true
+	}
+	return false
 }
 
 // ReadData8 reads 8 bit tiff images into a 1d slice.
 func ReadData8(r io.ReadSeeker, h Header, t Tags) ([]uint8, error) {
-	var data []uint8
-	for i, offset := range t.StripOffsets {
-		// seek r to offset
-		if _, err := r.Seek(int64(offset), 0); err != nil {
-			return data, err
-		}
-
-		// read into slice
-		numVals := t.StripByteCounts[i] / (uint32(t.BitsPerSample) / 8)
-		stripData := make([]uint8, numVals)
-		if err := binary.Read(r, h.ByteOrder, &stripData); err != nil {
-			return data, err
-		}
-		data = append(data, stripData...)
-	}
-	return data, nil
+	return readRegion8(readerAt(r), h, t, region{0, 0, t.ImageWidth, t.ImageLength}, 1)
 }
 
 // ReadData16 reads 16 bit tiff image into a 1d slice.
 func ReadData16(r io.ReadSeeker, h Header, t Tags) ([]uint16, error) {
-	var data []uint16
-	for i, offset := range t.StripOffsets {
-		// seek r to offset
-		if _, err := r.Seek(int64(offset), 0); err != nil {
-			return data, err
-		}
-
-		// read into slice
-		numVals := t.StripByteCounts[i] / (uint32(t.BitsPerSample) / 8)
-		stripData := make([]uint16, numVals)
-		if err := binary.Read(r, h.ByteOrder, &stripData); err != nil {
-			return data, err
-		}
-		data = append(data, stripData...)
-	}
-	return data, nil
+	return readRegion16(readerAt(r), h, t, region{0, 0, t.ImageWidth, t.ImageLength}, 1)
 }
 
 // ReadData32 reads 32 bit float tiff image into a 1d slice.
 func ReadData32(r io.ReadSeeker, h Header, t Tags) ([]float32, error) {
-	var data []float32
-	for i, offset := range t.StripOffsets {
-		// seek r to offset
-		if _, err := r.Seek(int64(offset), 0); err != nil {
-			return data, err
+	return readRegion32(readerAt(r), h, t, region{0, 0, t.ImageWidth, t.ImageLength}, 1)
+}
+
+// ReadInto8 reads an 8 bit tiff image into data, which must hold at least width*length samples.
+// Reading images of the same size into the same slice does not allocate, apart from inside compress/flate for deflate data.
+func ReadInto8(r io.ReadSeeker, h Header, t Tags, data []uint8) error {
+	return readInto8(readerAt(r), h, t, region{0, 0, t.ImageWidth, t.ImageLength}, 1, data)
+}
+
+// ReadInto16 reads a 16 bit tiff image into data, which must hold at least width*length samples.
+// Reading images of the same size into the same slice does not allocate, apart from inside compress/flate for deflate data.
+func ReadInto16(r io.ReadSeeker, h Header, t Tags, data []uint16) error {
+	return readInto16(readerAt(r), h, t, region{0, 0, t.ImageWidth, t.ImageLength}, 1, data)
+}
+
+// ReadInto32 reads a 32 bit float tiff image into data, which must hold at least width*length samples.
+// Reading images of the same size into the same slice does not allocate, apart from inside compress/flate for deflate data.
+func ReadInto32(r io.ReadSeeker, h Header, t Tags, data []float32) error {
+	return readInto32(readerAt(r), h, t, region{0, 0, t.ImageWidth, t.ImageLength}, 1, data)
+}
+
+// read and decode strip or tile i into block, which must be the uncompressed size of the block
+func readBlock(r io.ReaderAt, h Header, t Tags, l layout, i int, block []byte) error {
+	if l.sparse(i) {
+		sample, n := noDataSample(t, h.ByteOrder)
+		for j := 0; j < len(block); j += n {
+			copy(block[j:], sample[:n])
 		}
+		return nil
+	}
 
-		// read into slice
-		numVals := t.StripByteCounts[i] / (uint32(t.BitsPerSample) / 8)
-		stripData := make([]float32, numVals)
-		if err := binary.Read(r, h.ByteOrder, &stripData); err != nil {
-			return data, err
+	var n int
+	var err error
+	if t.Compression == 0 || t.Compression == CompressionNone {
+		// read directly into block
+		count := l.counts[i]
+		if count > uint64(len(block)) {
+			count = uint64(len(block))
 		}
-		data = append(data, stripData...)
+		n, err = readFullAt(r, block[:count], int64(l.offsets[i]))
+	} else {
+		// compressed data is never much larger than the block it decodes to, so a larger
+		// byte count is not trusted when allocating
+		count := l.counts[i]
+		if max := 2*uint64(len(block)) + 1024; count > max {
+			count = max
+		}
+		if min := minCompressed(t.Compression, uint64(len(block))); count < min {
+			return l.blockError(i, fmt.Errorf("%w, %d bytes can't decode to the %d bytes of the block", ErrCorrupt, count, len(block)))
+		}
+		src := getScratch(int(count))
+		defer scratchPool.Put(src)
+		if _, err = readFullAt(r, *src, int64(l.offsets[i])); err != nil {
+			return l.blockError(i, err)
+		}
+		n, err = decompress(t.Compression, block, *src)
+	}
+	if err != nil {
+		return l.blockError(i, err)
+	}
+	if n < len(block) {
+		return l.blockError(i, fmt.Errorf("%w, expected %d bytes, got %d", ErrTruncated, len(block), n))
+	}
+
+	if err := undoPredictor(t.Predictor, block, int(l.blockWidth), int(l.bytesPerSample), h.ByteOrder); err != nil {
+		return l.blockError(i, err)
+	}
+	return nil
+}
+
+// r as an io.ReaderAt, using its own ReadAt if it has one such as *os.File and *bytes.Reader
+func readerAt(r io.ReadSeeker) io.ReaderAt {
+	if ra, ok := r.(io.ReaderAt); ok {
+		return ra
 	}
-	return data, nil
+	return seekReaderAt{r}
+}
+
+// read len(p) bytes at off from r, which may return io.EOF along with all of them
+// when the read ends at the end of the file
+func readFullAt(r io.ReaderAt, p []byte, off int64) (int, error) {
+	n, err := r.ReadAt(p, off)
+	if n == len(p) && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// seekReaderAt adapts an io.ReadSeeker to an io.ReaderAt, it is not safe for concurrent use
+type seekReaderAt struct {
+	r io.ReadSeeker
+}
+
+func (s seekReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if _, err := s.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(s.r, p)
 }
 
 // get value of an uint16 tag
@@ -253,6 +459,10 @@ func getTagValue16or32(r io.ReadSeeker, p *uint32, byteOrder binary.ByteOrder, d
 		*p = uint32(val16)
 	case 4:
 		err = getTagValue32(r, p, byteOrder, de)
+	case 16:
+		var val64 uint64
+		err = binary.Read(r, byteOrder, &val64)
+		*p = uint32(val64)
 	}
 	if err != nil {
 		return err
@@ -261,19 +471,128 @@ func getTagValue16or32(r io.ReadSeeker, p *uint32, byteOrder binary.ByteOrder, d
 	return nil
 }
 
-// populate slice with multiple values, reads uint16 or uint32 depending on type specified in directory entry and always returns uint32
-func getMultiTagValues16or32(r io.ReadSeeker, p *[]uint32, byteOrder binary.ByteOrder, de directoryEntry) error {
-	var newVal uint32
+// populate slice with multiple values, reads uint16, uint32 or uint64 depending on type specified in directory entry and always returns uint64
+func getMultiTagValues(r io.ReadSeeker, p *[]uint64, byteOrder binary.ByteOrder, de directoryEntry) error {
+	if _, err := r.Seek(int64(de.ValueOffset), 0); err != nil {
+		return err
+	}
 
-	for i := uint32(0); i < de.Count; i++ {
-		if err := getTagValue16or32(r, &newVal, byteOrder, de); err != nil {
-			return err
+	vals := make([]uint64, de.Count)
+	var err error
+	switch de.DType {
+	case 3:
+		val16 := make([]uint16, de.Count)
+		err = binary.Read(r, byteOrder, val16)
+		for i, v := range val16 {
+			vals[i] = uint64(v)
+		}
+	case 4:
+		val32 := make([]uint32, de.Count)
+		err = binary.Read(r, byteOrder, val32)
+		for i, v := range val32 {
+			vals[i] = uint64(v)
 		}
-		*p = append(*p, newVal)
+	case 16:
+		err = binary.Read(r, byteOrder, vals)
+	default:
+		err = fmt.Errorf("%w, expected short, long or long8 type, got %d", ErrUnsupportedType, de.DType)
+	}
+	if err != nil {
+		return err
+	}
+	*p = vals
 
-		next, _ := typeToBytes(de.DType)
-		de.ValueOffset += uint32(next)
+	return nil
+}
+
+// read a directory entry
+func readDirectoryEntry(r io.Reader, h Header) (directoryEntry, error) {
+	if h.BigTIFF {
+		var de directoryEntry
+		err := binary.Read(r, h.ByteOrder, &de)
+		return de, err
+	}
+
+	var de directoryEntry32
+	if err := binary.Read(r, h.ByteOrder, &de); err != nil {
+		return directoryEntry{}, err
+	}
+	return directoryEntry{de.Tag, de.DType, uint64(de.Count), uint64(de.ValueOffset)}, nil
+}
+
+// read an entry count (size 2) or offset (size 4) of an IFD, both are 8 bytes in BigTIFF
+func readIFDValue(r io.Reader, h Header, size int) (uint64, error) {
+	var err error
+	switch {
+	case h.BigTIFF:
+		var v uint64
+		err = binary.Read(r, h.ByteOrder, &v)
+		return v, err
+	case size == 2:
+		var v uint16
+		err = binary.Read(r, h.ByteOrder, &v)
+		return uint64(v), err
+	default:
+		var v uint32
+		err = binary.Read(r, h.ByteOrder, &v)
+		return uint64(v), err
+	}
+}
+
+// get numerator and denominator of a rational tag
+func getRational(r io.ReadSeeker, p *[]uint32, byteOrder binary.ByteOrder, de directoryEntry) error {
+	if de.DType != 5 {
+		return fmt.Errorf("%w, expected rational type 5, got %d", ErrUnsupportedType, de.DType)
+	}
+
+	if _, err := r.Seek(int64(de.ValueOffset), 0); err != nil {
+		return err
+	}
+
+	val := make([]uint32, 2)
+	if err := binary.Read(r, byteOrder, val); err != nil {
+		return err
+	}
+	*p = val
+
+	return nil
+}
+
+// get value of an ascii tag, the trailing NUL is removed and NULs separating multiple strings are kept
+func getASCII(r io.ReadSeeker, p *string, de directoryEntry) error {
+	if de.DType != 2 {
+		return fmt.Errorf("%w, expected ascii type 2, got %d", ErrUnsupportedType, de.DType)
+	}
+
+	if _, err := r.Seek(int64(de.ValueOffset), 0); err != nil {
+		return err
+	}
+
+	val := make([]byte, de.Count)
+	if _, err := io.ReadFull(r, val); err != nil {
+		return err
+	}
+	*p = strings.TrimRight(string(val), "\x00")
+
+	return nil
+}
+
+// append the raw value of a tag without a dedicated field in Tags
+func getField(r io.ReadSeeker, p *[]Field, byteOrder binary.ByteOrder, de directoryEntry) error {
+	typeBytes, err := typeToBytes(de.DType)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.Seek(int64(de.ValueOffset), 0); err != nil {
+		return err
+	}
+
+	val := make([]byte, uint64(typeBytes)*de.Count)
+	if _, err := io.ReadFull(r, val); err != nil {
+		return err
 	}
+	*p = append(*p, Field{de.Tag, de.DType, de.Count, val, byteOrder})
 
 	return nil
 }
@@ -295,8 +614,30 @@ func typeToBytes(t uint16) (uint16, error) {
 		typeBytes = 4 // long
 	case 5:
 		typeBytes = 8 // rational
+	case 6:
+		typeBytes = 1 // sbyte
+	case 7:
+		typeBytes = 1 // undefined
+	case 8:
+		typeBytes = 2 // sshort
+	case 9:
+		typeBytes = 4 // slong
+	case 10:
+		typeBytes = 8 // srational
+	case 11:
+		typeBytes = 4 // float
+	case 12:
+		typeBytes = 8 // double
+	case 13:
+		typeBytes = 4 // ifd
+	case 16:
+		typeBytes = 8 // long8 (BigTIFF)
+	case 17:
+		typeBytes = 8 // slong8 (BigTIFF)
+	case 18:
+		typeBytes = 8 // ifd8 (BigTIFF)
 	default:
-		err = fmt.Errorf("type not supported, got %d, expected [1,5]", t)
+		err = fmt.Errorf("%w, got %d, expected [1,13] or [16,18]", ErrUnsupportedType, t)
 	}
 	return typeBytes, err
 }
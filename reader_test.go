@@ -1,6 +1,8 @@
 package gtiff
 
 import (
+	"bytes"
+	"encoding/binary"
 	"os"
 	"reflect"
 	"testing"
@@ -87,3 +89,149 @@ func TestReadIntegration32(t *testing.T) {
 	}
 
 }
+
+func TestReadASCII(t *testing.T) {
+	r, err := os.Open("./test-images/cell8.tif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	tags, _, err := ReadTags(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tags.ImageDescription != "image description" {
+		t.Errorf("expected %q, got %q", "image description", tags.ImageDescription)
+	}
+	if tags.Artist != "Jan" {
+		t.Errorf("expected %q, got %q", "Jan", tags.Artist)
+	}
+}
+
+func TestReadInto(t *testing.T) {
+	const width, length = 64, 50
+	data := make([]uint16, width*length)
+	for i := range data {
+		data[i] = uint16(i * 3)
+	}
+
+	for i, opt := range []Options{
+		{ByteOrder: binary.BigEndian},
+		{Compression: CompressionLZW, Predictor: PredictorHorizontal, RowsPerStrip: 7},
+		{Compression: CompressionDeflate, TileWidth: 16, TileLength: 32},
+		{Compression: CompressionPackBits},
+	} {
+		f := &memFile{}
+		if err := NewEncoder(f, &opt).Encode16(data, width, length); err != nil {
+			t.Fatal(err)
+		}
+		r := bytes.NewReader(f.buf)
+		tags, header, err := ReadTags(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := make([]uint16, width*length+10)
+		if err := ReadInto16(r, header, tags, got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(data, got[:width*length]) {
+			t.Errorf("options %d: data does not match data written", i)
+		}
+		if err := ReadInto16(r, header, tags, got[:width*length-1]); err == nil {
+			t.Errorf("options %d: expected error reading into slice too small", i)
+		}
+		if err := ReadInto8(r, header, tags, make([]uint8, width*length)); err == nil {
+			t.Errorf("options %d: expected error reading 16 bit image as 8 bit", i)
+		}
+
+		// compress/flate allocates when building some huffman tables
+		if raceEnabled || opt.Compression == CompressionDeflate {
+			continue
+		}
+		allocs := testing.AllocsPerRun(10, func() {
+			if err := ReadInto16(r, header, tags, got); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("options %d: expected no allocations, got %v", i, allocs)
+		}
+	}
+
+	// the floating point predictor reorders bytes through scratch space, which is reused too
+	data32 := make([]float32, width*length)
+	for i := range data32 {
+		data32[i] = float32(i) / 3
+	}
+	f := &memFile{}
+	if err := NewEncoder(f, &Options{Compression: CompressionLZW, Predictor: PredictorFloatingPoint, RowsPerStrip: 7}).Encode32(data32, width, length); err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(f.buf)
+	tags, header, err := ReadTags(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]float32, width*length)
+	if err := ReadInto32(r, header, tags, got); err != nil || !reflect.DeepEqual(data32, got) {
+		t.Errorf("floating point predictor: data does not match data written, %v", err)
+	}
+	if raceEnabled {
+		return
+	}
+	allocs := testing.AllocsPerRun(10, func() {
+		if err := ReadInto32(r, header, tags, got); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("floating point predictor: expected no allocations, got %v", allocs)
+	}
+}
+
+func TestReadTagsPages(t *testing.T) {
+	page := func(width uint32) func(d *ifdBuilder) {
+		return func(d *ifdBuilder) {
+			d.long(256, width)
+			d.long(257, 1)
+			d.short(258, 8)
+			d.short(262, 1)
+			d.long(273, 8)
+			d.long(279, width)
+			d.short(65000, uint16(width))
+		}
+	}
+	// a second page of the first 4 pixels, linked from the first
+	file := buildTiff(page(8))
+	second := &ifdBuilder{byteOrder: binary.LittleEndian}
+	page(4)(second)
+	binary.LittleEndian.PutUint32(file[16+2+7*12:], uint32(len(file)))
+	file = append(file, second.encode(uint64(len(file)))...)
+
+	tags, header, err := ReadTags(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags.ImageWidth != 8 || len(tags.Extra) != 1 || tags.Extra[0].Value[0] != 8 {
+		t.Errorf("expected the tags of the first page, got width %d and %d extra tags", tags.ImageWidth, len(tags.Extra))
+	}
+	data, err := ReadData8(bytes.NewReader(file), header, tags)
+	if err != nil || !reflect.DeepEqual(data, []uint8{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Errorf("expected the data of the first page, got %v %v", data, err)
+	}
+
+	r, err := NewReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.NumPages() != 2 {
+		t.Fatalf("expected 2 pages, got %d", r.NumPages())
+	}
+	tags, _ = r.Tags(1)
+	if tags.ImageWidth != 4 || len(tags.Extra) != 1 || tags.Extra[0].Value[0] != 4 {
+		t.Errorf("expected the tags of the second page, got width %d and %d extra tags", tags.ImageWidth, len(tags.Extra))
+	}
+}
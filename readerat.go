@@ -0,0 +1,318 @@
+package gtiff
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// Reader reads the pages of a tiff file through an io.ReaderAt. The header and the IFD of every page
+// are parsed once by NewReader, after which all methods are safe for concurrent use as long as r is,
+// as *os.File is.
+type Reader struct {
+	r     io.ReaderAt
+	h     Header
+	pages []Tags
+	opt   ReadOptions
+}
+
+// ReadOptions configures a Reader.
+type ReadOptions struct {
+	// Concurrency is the number of goroutines decoding the strips or tiles of a page or region
+	// in parallel, such as runtime.NumCPU(). 0 or 1 decodes them one at a time.
+	Concurrency int
+
+	// Limits bound the resources used reading the file, nil uses DefaultLimits.
+	Limits *Limits
+
+	// Mode sets how problems with files that don't follow the tiff spec are handled.
+	Mode Mode
+
+	// Warn, if set, is called with each problem found while parsing that is not returned as an error.
+	Warn func(err error)
+}
+
+// Mode sets how files that don't follow the tiff spec are read.
+type Mode int
+
+const (
+	// ModeDefault returns an error for problems that stop an image being read correctly
+	// and passes others to ReadOptions.Warn.
+	ModeDefault Mode = iota
+
+	// ModeStrict returns an error for any violation of the spec found while parsing,
+	// such as tags out of ascending order or byte counts that don't match the image.
+	ModeStrict
+
+	// ModeLenient works around problems where it can, such as byte counts past the end of the file
+	// or IFDs that are not word aligned, and passes them to ReadOptions.Warn.
+	ModeLenient
+)
+
+// NewReader parses the header and all IFDs of the tiff file read through r.
+func NewReader(r io.ReaderAt) (*Reader, error) {
+	return NewReaderOptions(r, nil)
+}
+
+// NewReaderOptions parses the header and all IFDs of the tiff file read through r and reads pages with opt.
+// A nil opt uses the defaults.
+func NewReaderOptions(r io.ReaderAt, opt *ReadOptions) (*Reader, error) {
+	// parsing reads sequentially through a private section reader, r is never seeked
+	s := io.NewSectionReader(r, 0, math.MaxInt64)
+	h, err := ReadHeader(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var pages []Tags
+	p := newParser(h, readerAtSize(r), opt)
+	for next := h.IFDOffset; next != 0; {
+		if len(pages) == p.lim.MaxIFDs {
+			return nil, fmt.Errorf("%w: more than %d IFDs", ErrLimit, p.lim.MaxIFDs)
+		}
+		if err := p.next(next); err != nil {
+			return nil, err
+		}
+		var t Tags
+		if next, err = readIFD(s, next, &t, p); err != nil {
+			return nil, err
+		}
+		pages = append(pages, t)
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("parse: %w, no IFD", ErrInvalidHeader)
+	}
+
+	reader := &Reader{r: r, h: h, pages: pages}
+	if opt != nil {
+		reader.opt = *opt
+	}
+	return reader, nil
+}
+
+// Header returns the header of the file.
+func (r *Reader) Header() Header {
+	return r.h
+}
+
+// NumPages returns the number of pages (IFDs) in the file.
+func (r *Reader) NumPages() int {
+	return len(r.pages)
+}
+
+// Tags returns the tags of a page, the first page is 0.
+func (r *Reader) Tags(page int) (Tags, error) {
+	if page < 0 || page >= len(r.pages) {
+		return Tags{}, fmt.Errorf("read: page %d %w, file has %d pages", page, ErrOutOfRange, len(r.pages))
+	}
+	return r.pages[page], nil
+}
+
+// NumBlocks returns the number of strips, or tiles for a tiled image, of a page.
+func (r *Reader) NumBlocks(page int) (int, error) {
+	t, err := r.Tags(page)
+	if err != nil {
+		return 0, err
+	}
+	l, err := readLayout(t)
+	if err != nil {
+		return 0, err
+	}
+	return l.blocks(), nil
+}
+
+// Block reads and decodes strip or tile i of a page into a slice of bytes in the byte order of the file.
+// Tiles include the padding past the right and bottom edges of the image.
+func (r *Reader) Block(page, i int) ([]byte, error) {
+	t, err := r.Tags(page)
+	if err != nil {
+		return nil, err
+	}
+	l, err := readLayout(t)
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= l.blocks() {
+		return nil, fmt.Errorf("read: block %d %w, page has %d blocks", i, ErrOutOfRange, l.blocks())
+	}
+
+	block := make([]byte, l.blockBytes(i))
+	if err := readBlock(r.r, r.h, t, l, i, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// Data8 reads an 8 bit page into a 1d slice.
+func (r *Reader) Data8(page int) ([]uint8, error) {
+	return r.Region8(page, 0, 0, r.width(page), r.length(page))
+}
+
+// Data16 reads a 16 bit page into a 1d slice.
+func (r *Reader) Data16(page int) ([]uint16, error) {
+	return r.Region16(page, 0, 0, r.width(page), r.length(page))
+}
+
+// Data32 reads a 32 bit float page into a 1d slice.
+func (r *Reader) Data32(page int) ([]float32, error) {
+	return r.Region32(page, 0, 0, r.width(page), r.length(page))
+}
+
+// Data reads a page into a 1d slice of the type matching its bits per sample and SampleFormat:
+// a []uint8, []uint16 or []uint32 for unsigned integers (1), a []int8, []int16 or []int32 for
+// signed integers (2) and a []float32 for floating point (3). Pages without a SampleFormat tag
+// are unsigned integers, the default of the tiff spec, including 32 bit pages.
+// Other sample formats return ErrUnsupportedType.
+func (r *Reader) Data(page int) (interface{}, error) {
+	t, err := r.Tags(page)
+	if err != nil {
+		return nil, err
+	}
+	format := t.SampleFormat
+	if format == 0 {
+		format = 1
+	}
+	switch {
+	case t.BitsPerSample != 8 && t.BitsPerSample != 16 && t.BitsPerSample != 32:
+		return nil, fmt.Errorf("read: %w, got %d, expected 8, 16 or 32", ErrUnsupportedBitsPerSample, t.BitsPerSample)
+	case format != 1 && format != 2 && (format != 3 || t.BitsPerSample != 32):
+		return nil, fmt.Errorf("read: %w, sample format %d of %d bit samples", ErrUnsupportedType, t.SampleFormat, t.BitsPerSample)
+	}
+
+	switch t.BitsPerSample {
+	case 8:
+		d, err := r.Data8(page)
+		if err != nil || format == 1 {
+			return d, err
+		}
+		return int8s(d), nil
+	case 16:
+		d, err := r.Data16(page)
+		if err != nil || format == 1 {
+			return d, err
+		}
+		return int16s(d), nil
+	default:
+		d, err := r.Data32(page)
+		switch {
+		case err != nil || format == 3:
+			return d, err
+		case format == 1:
+			return uint32s(d), nil
+		}
+		return int32s(d), nil
+	}
+}
+
+// Float64 reads a page of any bits per sample and SampleFormat supported by Data into a 1d slice of float64.
+func (r *Reader) Float64(page int) ([]float64, error) {
+	data, err := r.Data(page)
+	if err != nil {
+		return nil, err
+	}
+	var out []float64
+	switch d := data.(type) {
+	case []uint8:
+		out = make([]float64, len(d))
+		for i, v := range d {
+			out[i] = float64(v)
+		}
+	case []int8:
+		out = make([]float64, len(d))
+		for i, v := range d {
+			out[i] = float64(v)
+		}
+	case []uint16:
+		out = make([]float64, len(d))
+		for i, v := range d {
+			out[i] = float64(v)
+		}
+	case []int16:
+		out = make([]float64, len(d))
+		for i, v := range d {
+			out[i] = float64(v)
+		}
+	case []uint32:
+		out = make([]float64, len(d))
+		for i, v := range d {
+			out[i] = float64(v)
+		}
+	case []int32:
+		out = make([]float64, len(d))
+		for i, v := range d {
+			out[i] = float64(v)
+		}
+	case []float32:
+		out = make([]float64, len(d))
+		for i, v := range d {
+			out[i] = float64(v)
+		}
+	}
+	return out, nil
+}
+
+// DataInto8 reads an 8 bit page into data, which must hold at least width*length samples.
+func (r *Reader) DataInto8(page int, data []uint8) error {
+	t, err := r.Tags(page)
+	if err != nil {
+		return err
+	}
+	return readInto8(r.r, r.h, t, region{0, 0, t.ImageWidth, t.ImageLength}, r.opt.Concurrency, data)
+}
+
+// DataInto16 reads a 16 bit page into data, which must hold at least width*length samples.
+func (r *Reader) DataInto16(page int, data []uint16) error {
+	t, err := r.Tags(page)
+	if err != nil {
+		return err
+	}
+	return readInto16(r.r, r.h, t, region{0, 0, t.ImageWidth, t.ImageLength}, r.opt.Concurrency, data)
+}
+
+// DataInto32 reads a 32 bit float page into data, which must hold at least width*length samples.
+func (r *Reader) DataInto32(page int, data []float32) error {
+	t, err := r.Tags(page)
+	if err != nil {
+		return err
+	}
+	return readInto32(r.r, r.h, t, region{0, 0, t.ImageWidth, t.ImageLength}, r.opt.Concurrency, data)
+}
+
+// Region8 reads the width x length region with its top left corner at x, y of an 8 bit page into a 1d slice.
+func (r *Reader) Region8(page int, x, y, width, length uint32) ([]uint8, error) {
+	t, err := r.Tags(page)
+	if err != nil {
+		return nil, err
+	}
+	return readRegion8(r.r, r.h, t, region{x, y, width, length}, r.opt.Concurrency)
+}
+
+// Region16 reads the width x length region with its top left corner at x, y of a 16 bit page into a 1d slice.
+func (r *Reader) Region16(page int, x, y, width, length uint32) ([]uint16, error) {
+	t, err := r.Tags(page)
+	if err != nil {
+		return nil, err
+	}
+	return readRegion16(r.r, r.h, t, region{x, y, width, length}, r.opt.Concurrency)
+}
+
+// Region32 reads the width x length region with its top left corner at x, y of a 32 bit float page into a 1d slice.
+func (r *Reader) Region32(page int, x, y, width, length uint32) ([]float32, error) {
+	t, err := r.Tags(page)
+	if err != nil {
+		return nil, err
+	}
+	return readRegion32(r.r, r.h, t, region{x, y, width, length}, r.opt.Concurrency)
+}
+
+// width of a page, 0 if the page does not exist
+func (r *Reader) width(page int) uint32 {
+	t, _ := r.Tags(page)
+	return t.ImageWidth
+}
+
+// length of a page, 0 if the page does not exist
+func (r *Reader) length(page int) uint32 {
+	t, _ := r.Tags(page)
+	return t.ImageLength
+}
@@ -0,0 +1,244 @@
+package gtiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	const width, length = 50, 40
+	data := make([]uint16, width*length)
+	for i := range data {
+		data[i] = uint16(i * 7)
+	}
+
+	f := &memFile{}
+	opt := Options{Compression: CompressionLZW, TileWidth: 16, TileLength: 16}
+	if err := NewEncoder(f, &opt).Encode16(data, width, length); err != nil {
+		t.Fatal(err)
+	}
+
+	// add a second page sharing the first page's data by copying its IFD to the end of the file
+	b := f.buf
+	ifd := binary.LittleEndian.Uint32(b[4:])
+	n := binary.LittleEndian.Uint16(b[ifd:])
+	size := 2 + 12*uint32(n)
+	next := uint32(len(b))
+	b = append(b, b[ifd:ifd+size]...)
+	b = append(b, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(b[ifd+size:], next)
+
+	r, err := NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.NumPages() != 2 {
+		t.Fatalf("expected 2 pages, got %d", r.NumPages())
+	}
+	if blocks, err := r.NumBlocks(1); err != nil || blocks != 12 {
+		t.Errorf("expected 12 tiles, got %d, %v", blocks, err)
+	}
+
+	// read pages and regions from many goroutines at once
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			page := i % 2
+			got, err := r.Data16(page)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if !reflect.DeepEqual(data, got) {
+				t.Errorf("page %d: data does not match data written", page)
+			}
+			if err := r.DataInto16(page, got); err != nil {
+				errs <- err
+				return
+			}
+			if !reflect.DeepEqual(data, got) {
+				t.Errorf("page %d: data read into slice does not match data written", page)
+			}
+			y := uint32(i)
+			reg, err := r.Region16(page, 3, y, 20, 10)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if reg[0] != data[y*width+3] || reg[len(reg)-1] != data[(y+9)*width+22] {
+				t.Errorf("page %d: region at row %d does not match data written", page, y)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if _, err := r.Data16(2); err == nil {
+		t.Error("expected error reading page past end of file")
+	}
+	if _, err := r.Block(0, 12); err == nil {
+		t.Error("expected error reading block past end of page")
+	}
+	if _, err := r.Data8(0); err == nil {
+		t.Error("expected error reading 16 bit page as 8 bit")
+	}
+}
+
+func TestReaderFile(t *testing.T) {
+	f, err := os.Open("./test-images/cell8.tif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tags, header, err := ReadTags(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ReadData8(f, header, tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.Data8(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, got) {
+		t.Error("data read by Reader does not match ReadData8")
+	}
+
+	// strips concatenate to the image
+	blocks, err := r.NumBlocks(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var strips []byte
+	for i := 0; i < blocks; i++ {
+		b, err := r.Block(0, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		strips = append(strips, b...)
+	}
+	if !bytes.Equal(expected, strips) {
+		t.Error("strips read by Reader do not match ReadData8")
+	}
+}
+
+func TestReaderConcurrency(t *testing.T) {
+	const width, length = 300, 257
+	data := make([]float32, width*length)
+	for i := range data {
+		data[i] = float32(i%width) * float32(i/width)
+	}
+
+	for _, opt := range []Options{
+		{RowsPerStrip: 3},
+		{Compression: CompressionDeflate, Predictor: PredictorFloatingPoint, RowsPerStrip: 10},
+		{Compression: CompressionLZW, TileWidth: 32, TileLength: 48},
+	} {
+		f := &memFile{}
+		if err := NewEncoder(f, &opt).Encode32(data, width, length); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, concurrency := range []int{0, 1, 4, 100} {
+			r, err := NewReaderOptions(bytes.NewReader(f.buf), &ReadOptions{Concurrency: concurrency})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.Data32(0)
+			if err != nil {
+				t.Fatalf("%+v, concurrency %d: %v", opt, concurrency, err)
+			}
+			if !reflect.DeepEqual(data, got) {
+				t.Errorf("%+v, concurrency %d: data does not match data written", opt, concurrency)
+			}
+			reg, err := r.Region32(0, 17, 100, 200, 50)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reg[0] != data[100*width+17] || reg[len(reg)-1] != data[149*width+216] {
+				t.Errorf("%+v, concurrency %d: region does not match data written", opt, concurrency)
+			}
+		}
+	}
+
+	// errors from any worker are reported, the forward encoder writes the IFD before the strips
+	var buf bytes.Buffer
+	opt := Options{Compression: CompressionLZW, RowsPerStrip: 8}
+	if err := NewForwardEncoder(&buf, &opt).Encode32(data, width, length); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReaderOptions(bytes.NewReader(buf.Bytes()[:buf.Len()/2]), &ReadOptions{Concurrency: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Data32(0); err == nil {
+		t.Error("expected error reading truncated file")
+	}
+}
+
+// eofReaderAt returns io.EOF along with the last bytes of the file, as the io.ReaderAt contract allows
+type eofReaderAt struct {
+	*bytes.Reader
+}
+
+func (r eofReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.Reader.ReadAt(p, off)
+	if err == nil && off+int64(n) == r.Size() {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func TestReaderAtEOF(t *testing.T) {
+	data := make([]uint16, 30*20)
+	for i := range data {
+		data[i] = uint16(i * 3)
+	}
+	for _, opt := range []Options{
+		{RowsPerStrip: 8},
+		{Compression: CompressionLZW, RowsPerStrip: 8},
+	} {
+		// the last strip ends at the end of the file
+		var buf bytes.Buffer
+		if err := NewForwardEncoder(&buf, &opt).Encode16(data, 30, 20); err != nil {
+			t.Fatal(err)
+		}
+		r := eofReaderAt{bytes.NewReader(buf.Bytes())}
+		reader, err := NewReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := reader.Data16(0); err != nil || !reflect.DeepEqual(data, got) {
+			t.Errorf("compression %d: data changed, %v", opt.Compression, err)
+		}
+		if _, err := reader.Block(0, 2); err != nil {
+			t.Errorf("compression %d: %v", opt.Compression, err)
+		}
+		tags, header, err := ReadTags(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := ReadData16(r, header, tags); err != nil || !reflect.DeepEqual(data, got) {
+			t.Errorf("compression %d: ReadData16 data changed, %v", opt.Compression, err)
+		}
+	}
+}
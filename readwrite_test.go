@@ -1,7 +1,13 @@
 package gtiff
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -36,3 +42,369 @@ func TestReadWrite32(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestReadWriteResolution(t *testing.T) {
+	var tags Tags
+	tags.ImageWidth = 5
+	tags.ImageLength = 5
+	tags.SetPixelSize(0.65)
+
+	f := &memFile{}
+	if err := WriteTiffTags16(f, binary.BigEndian, data16, tags); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Seek(0, io.SeekStart)
+	got, _, err := ReadTags(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tags.XResolution, got.XResolution) || !reflect.DeepEqual(tags.YResolution, got.YResolution) {
+		t.Errorf("expected resolution %v %v, got %v %v", tags.XResolution, tags.YResolution, got.XResolution, got.YResolution)
+	}
+	if got.ResolutionUnit != ResolutionUnitCentimeter {
+		t.Errorf("expected unit %d, got %d", ResolutionUnitCentimeter, got.ResolutionUnit)
+	}
+	x, y, err := got.PixelSizeMicrons()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(x-0.65) > 1e-9 || math.Abs(y-0.65) > 1e-9 {
+		t.Errorf("expected pixel size 0.65, got %v %v", x, y)
+	}
+}
+
+// memFile is an in memory io.ReadWriteSeeker
+type memFile struct {
+	buf []byte
+	off int64
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.off >= int64(len(f.buf)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.buf[f.off:])
+	f.off += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if end := f.off + int64(len(p)); end > int64(len(f.buf)) {
+		f.buf = append(f.buf, make([]byte, end-int64(len(f.buf)))...)
+	}
+	n := copy(f.buf[f.off:], p)
+	f.off += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += int64(len(f.buf))
+	}
+	if offset < 0 {
+		return 0, errors.New("memFile: negative position")
+	}
+	f.off = offset
+	return offset, nil
+}
+
+func TestReadWriteASCII(t *testing.T) {
+	tags := Tags{
+		ImageWidth:       5,
+		ImageLength:      5,
+		ImageDescription: "exposure=100ms\ngain=2",
+		Artist:           "a",
+		PageName:         "first\x00second",
+	}
+	tags.Stamp("gtiff test")
+
+	f := &memFile{}
+	if err := WriteTiffTags8(f, binary.LittleEndian, data8, tags); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Seek(0, io.SeekStart)
+	got, _, err := ReadTags(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ImageDescription != tags.ImageDescription {
+		t.Errorf("expected %q, got %q", tags.ImageDescription, got.ImageDescription)
+	}
+	if got.Artist != tags.Artist {
+		t.Errorf("expected %q, got %q", tags.Artist, got.Artist)
+	}
+	if got.Software != "gtiff test" {
+		t.Errorf("expected %q, got %q", "gtiff test", got.Software)
+	}
+	if _, err := got.Time(); err != nil {
+		t.Error(err)
+	}
+	if pages := SplitASCII(got.PageName); !reflect.DeepEqual(pages, []string{"first", "second"}) {
+		t.Errorf("expected [first second], got %q", pages)
+	}
+	if got.Copyright != "" {
+		t.Errorf("expected empty Copyright, got %q", got.Copyright)
+	}
+}
+
+func TestReadWriteExtraTags(t *testing.T) {
+	r, err := os.Open("./test-images/cell8.tif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	tags, header, err := ReadTags(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data8, err := ReadData8(r, header, tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// write in the opposite byte order to check values are converted
+	f := &memFile{}
+	if err := WriteTiffTags8(f, binary.BigEndian, data8, tags); err != nil {
+		t.Fatal(err)
+	}
+
+	f.Seek(0, io.SeekStart)
+	got, gotHeader, err := ReadTags(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotHeader.ByteOrder != binary.BigEndian {
+		t.Fatalf("expected big endian")
+	}
+	if got.ImageDescription != tags.ImageDescription || got.Artist != tags.Artist {
+		t.Errorf("expected ascii tags %q %q, got %q %q", tags.ImageDescription, tags.Artist, got.ImageDescription, got.Artist)
+	}
+	if !reflect.DeepEqual(got.XResolution, tags.XResolution) || got.ResolutionUnit != tags.ResolutionUnit {
+		t.Errorf("expected resolution %v %d, got %v %d", tags.XResolution, tags.ResolutionUnit, got.XResolution, got.ResolutionUnit)
+	}
+
+	// raw bytes are unchanged by byte order
+	for _, tag := range []uint16{700, 59932} {
+		want, _ := tags.Field(tag)
+		field, ok := got.Field(tag)
+		if !ok {
+			t.Errorf("tag %d missing", tag)
+			continue
+		}
+		if !reflect.DeepEqual(want.Value, field.Value) {
+			t.Errorf("tag %d value changed", tag)
+		}
+	}
+
+	// shorts are converted
+	field, ok := got.Field(34021)
+	if !ok {
+		t.Fatalf("tag 34021 missing")
+	}
+	if v := binary.BigEndian.Uint16(field.Value); v != 16 {
+		t.Errorf("expected 16, got %d", v)
+	}
+
+	// pointer to exif ifd can't be copied
+	if _, ok := got.Field(34665); ok {
+		t.Errorf("expected exif ifd tag to be dropped")
+	}
+
+	gotData, err := ReadData8(f, gotHeader, got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data8, gotData) {
+		t.Errorf("data changed")
+	}
+}
+
+func TestEncodeOptions(t *testing.T) {
+	const width, length = 37, 29
+	d8 := make([]uint8, width*length)
+	d16 := make([]uint16, width*length)
+	d32 := make([]float32, width*length)
+	for i := range d8 {
+		d8[i] = uint8(i / 7)
+		d16[i] = uint16(i * 31)
+		d32[i] = float32(i%width) * 0.25
+	}
+
+	for _, compression := range []uint16{CompressionNone, CompressionLZW, CompressionDeflate, CompressionPackBits} {
+		for _, predictor := range []uint16{PredictorNone, PredictorHorizontal, PredictorFloatingPoint} {
+			if compression == CompressionNone && predictor != PredictorNone {
+				continue
+			}
+			for _, opt := range []Options{
+				{ByteOrder: binary.LittleEndian},
+				{ByteOrder: binary.BigEndian, RowsPerStrip: 4},
+				{ByteOrder: binary.LittleEndian, TileWidth: 16, TileLength: 32},
+				{ByteOrder: binary.BigEndian, RowsPerStrip: 10, BigTIFF: true},
+				{ByteOrder: binary.LittleEndian, TileWidth: 32, TileLength: 16, BigTIFF: true},
+			} {
+				opt.Compression = compression
+				opt.Predictor = predictor
+
+				if predictor != PredictorFloatingPoint {
+					f := &memFile{}
+					if err := NewEncoder(f, &opt).Encode8(d8, width, length); err != nil {
+						t.Fatal(err)
+					}
+					got, err := readBack(f, ReadData8)
+					if err != nil {
+						t.Fatalf("%+v: %v", opt, err)
+					}
+					if !reflect.DeepEqual(d8, got) {
+						t.Errorf("%+v: 8 bit data changed", opt)
+					}
+
+					f = &memFile{}
+					if err := NewEncoder(f, &opt).Encode16(d16, width, length); err != nil {
+						t.Fatal(err)
+					}
+					got16, err := readBack(f, ReadData16)
+					if err != nil {
+						t.Fatalf("%+v: %v", opt, err)
+					}
+					if !reflect.DeepEqual(d16, got16) {
+						t.Errorf("%+v: 16 bit data changed", opt)
+					}
+				}
+
+				f := &memFile{}
+				if err := NewEncoder(f, &opt).Encode32(d32, width, length); err != nil {
+					t.Fatal(err)
+				}
+				got32, err := readBack(f, ReadData32)
+				if err != nil {
+					t.Fatalf("%+v: %v", opt, err)
+				}
+				if !reflect.DeepEqual(d32, got32) {
+					t.Errorf("%+v: 32 bit data changed", opt)
+				}
+			}
+		}
+	}
+}
+
+func TestEncodeOptionErrors(t *testing.T) {
+	for _, opt := range []Options{
+		{Compression: 7},
+		{Predictor: PredictorHorizontal},
+		{Compression: CompressionLZW, Predictor: PredictorFloatingPoint},
+		{TileWidth: 20, TileLength: 16},
+	} {
+		if err := NewEncoder(&memFile{}, &opt).Encode8(data8, 5, 5); err == nil {
+			t.Errorf("%+v: expected error", opt)
+		}
+	}
+}
+
+func TestEncodeMetadata(t *testing.T) {
+	opt := Options{
+		Software:  "gtiff test",
+		ExtraTags: []Field{{Tag: 65000, Type: 3, Count: 1, Value: []byte{0, 7}, ByteOrder: binary.BigEndian}},
+	}
+	opt.Metadata.ImageDescription = "description"
+	opt.Metadata.SetPixelSize(2)
+
+	f := &memFile{}
+	if err := NewEncoder(f, &opt).Encode16(data16, 5, 5); err != nil {
+		t.Fatal(err)
+	}
+	f.Seek(0, io.SeekStart)
+	got, header, err := ReadTags(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.ByteOrder != binary.LittleEndian {
+		t.Errorf("expected little endian by default")
+	}
+	if got.Software != "gtiff test" || got.DateTime == "" || got.ImageDescription != "description" {
+		t.Errorf("expected metadata, got %q %q %q", got.Software, got.DateTime, got.ImageDescription)
+	}
+	if x, _, err := got.PixelSizeMicrons(); err != nil || math.Abs(x-2) > 1e-9 {
+		t.Errorf("expected pixel size 2, got %v %v", x, err)
+	}
+	field, ok := got.Field(65000)
+	if !ok || binary.LittleEndian.Uint16(field.Value) != 7 {
+		t.Errorf("expected extra tag with value 7, got %v", field)
+	}
+}
+
+// read back the first image in f
+func readBack(f *memFile, read interface{}) (interface{}, error) {
+	f.Seek(0, io.SeekStart)
+	tags, header, err := ReadTags(f)
+	if err != nil {
+		return nil, err
+	}
+	switch read := read.(type) {
+	case func(io.ReadSeeker, Header, Tags) ([]uint8, error):
+		return read(f, header, tags)
+	case func(io.ReadSeeker, Header, Tags) ([]uint16, error):
+		return read(f, header, tags)
+	case func(io.ReadSeeker, Header, Tags) ([]float32, error):
+		return read(f, header, tags)
+	}
+	return nil, errors.New("readBack: unknown read function")
+}
+
+func TestReadWriteSampleFormat(t *testing.T) {
+	src := Tags{ImageWidth: 2, ImageLength: 2, BitsPerSample: 16, PhotometricInterpretation: 0, SampleFormat: 2}
+	data := []int16{-1000, 0, 1000, -1}
+	f := &memFile{}
+	if err := WriteTiffTags16(f, binary.LittleEndian, uint16s(data), src); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(bytes.NewReader(f.buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, _ := r.Tags(0)
+	if tags.PhotometricInterpretation != 0 || tags.SampleFormat != 2 {
+		t.Errorf("expected WhiteIsZero signed samples, got photometric %d sample format %d", tags.PhotometricInterpretation, tags.SampleFormat)
+	}
+	got, err := r.Data(0)
+	if err != nil || !reflect.DeepEqual(got, data) {
+		t.Errorf("expected %v, got %v %v", data, got, err)
+	}
+
+	// signed 16 bit metadata does not apply to 8 bit data, and WhiteIsZero is kept without it
+	f = &memFile{}
+	if err := NewEncoder(f, &Options{Metadata: src}).Encode8(data8, 5, 5); err != nil {
+		t.Fatal(err)
+	}
+	f.Seek(0, io.SeekStart)
+	if tags, _, err = ReadTags(f); err != nil {
+		t.Fatal(err)
+	}
+	if tags.PhotometricInterpretation != 0 || tags.SampleFormat != 0 {
+		t.Errorf("expected WhiteIsZero unsigned samples, got photometric %d sample format %d", tags.PhotometricInterpretation, tags.SampleFormat)
+	}
+
+	// metadata not read from a file is BlackIsZero
+	f = &memFile{}
+	if err := NewEncoder(f, &Options{Metadata: Tags{Software: "gtiff"}}).Encode8(data8, 5, 5); err != nil {
+		t.Fatal(err)
+	}
+	f.Seek(0, io.SeekStart)
+	if tags, _, err = ReadTags(f); err != nil || tags.PhotometricInterpretation != 1 {
+		t.Errorf("expected BlackIsZero, got %d %v", tags.PhotometricInterpretation, err)
+	}
+}
+
+// copy of s as unsigned samples holding the same bits
+func uint16s(s []int16) []uint16 {
+	u := make([]uint16, len(s))
+	for i, v := range s {
+		u[i] = uint16(v)
+	}
+	return u
+}
@@ -0,0 +1,99 @@
+package gtiff
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+// RecoverData8 reads an 8 bit tiff image into a 1d slice like ReadData8 from a file that may be truncated
+// or damaged. Strips or tiles that can't be read are filled with fill, and the number of rows missing all
+// or part of their samples is returned. Errors are only returned if no part of the image can be read,
+// such as for an unsupported compression scheme. The tags of a truncated file are read in ModeDefault,
+// which passes strips or tiles cut short to ReadOptions.Warn, but not in ModeStrict.
+func RecoverData8(r io.ReadSeeker, h Header, t Tags, fill uint8) ([]uint8, uint32, error) {
+	reg := region{0, 0, t.ImageWidth, t.ImageLength}
+	if _, err := regionLayout(t, 8, reg); err != nil {
+		return nil, 0, err
+	}
+	data := make([]uint8, uint64(reg.width)*uint64(reg.length))
+	lost, err := recoverRegion(readerAt(r), h, t, 8, reg, data, []byte{fill})
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, lost, nil
+}
+
+// RecoverData16 reads a 16 bit tiff image into a 1d slice like ReadData16 from a file that may be truncated
+// or damaged, filling strips or tiles that can't be read with fill as RecoverData8 does.
+func RecoverData16(r io.ReadSeeker, h Header, t Tags, fill uint16) ([]uint16, uint32, error) {
+	reg := region{0, 0, t.ImageWidth, t.ImageLength}
+	if _, err := regionLayout(t, 16, reg); err != nil {
+		return nil, 0, err
+	}
+	data := make([]uint16, uint64(reg.width)*uint64(reg.length))
+	sample := make([]byte, 2)
+	h.ByteOrder.PutUint16(sample, fill)
+	lost, err := recoverRegion(readerAt(r), h, t, 16, reg, uint16Bytes(data), sample)
+	if err != nil {
+		return nil, 0, err
+	}
+	fromByteOrder16(data, h.ByteOrder)
+	return data, lost, nil
+}
+
+// RecoverData32 reads a 32 bit float tiff image into a 1d slice like ReadData32 from a file that may be
+// truncated or damaged, filling strips or tiles that can't be read with fill as RecoverData8 does.
+func RecoverData32(r io.ReadSeeker, h Header, t Tags, fill float32) ([]float32, uint32, error) {
+	reg := region{0, 0, t.ImageWidth, t.ImageLength}
+	if _, err := regionLayout(t, 32, reg); err != nil {
+		return nil, 0, err
+	}
+	data := make([]float32, uint64(reg.width)*uint64(reg.length))
+	sample := make([]byte, 4)
+	h.ByteOrder.PutUint32(sample, math.Float32bits(fill))
+	lost, err := recoverRegion(readerAt(r), h, t, 32, reg, float32Bytes(data), sample)
+	if err != nil {
+		return nil, 0, err
+	}
+	fromByteOrder32(data, h.ByteOrder)
+	return data, lost, nil
+}
+
+// read reg of an image into out in the byte order of the file, filling the part of each strip or tile
+// that can't be read with sample, and return the number of rows of reg missing any samples
+func recoverRegion(r io.ReaderAt, h Header, t Tags, bitsPerSample uint16, reg region, out, sample []byte) (uint32, error) {
+	l, err := regionLayout(t, bitsPerSample, reg)
+	if err != nil || reg.width == 0 || reg.length == 0 {
+		return 0, err
+	}
+
+	lost := make([]bool, reg.length)
+	var block []byte
+	x0, x1, y0, y1 := l.blockRange(reg)
+	for by := y0; by <= y1; by++ {
+		for bx := x0; bx <= x1; bx++ {
+			i := int(by*l.across + bx)
+			if block, err = decodeBlock(r, h, t, l, i, reg, out, block); err == nil {
+				continue
+			}
+			var blockErr *BlockError
+			if !errors.As(err, &blockErr) || errors.Is(err, ErrUnsupportedCompression) || errors.Is(err, ErrUnsupportedPredictor) {
+				return 0, err
+			}
+			in := l.intersect(i, reg)
+			l.fillRegion(out, sample, i, reg)
+			for row := in.y; row < in.y+in.length; row++ {
+				lost[row-reg.y] = true
+			}
+		}
+	}
+
+	var rows uint32
+	for _, missing := range lost {
+		if missing {
+			rows++
+		}
+	}
+	return rows, nil
+}
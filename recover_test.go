@@ -0,0 +1,111 @@
+package gtiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRecoverData(t *testing.T) {
+	data := make([]uint16, 64*32)
+	for i := range data {
+		data[i] = uint16(i)
+	}
+	for _, opt := range []Options{
+		{RowsPerStrip: 8},
+		{Compression: CompressionLZW, Predictor: PredictorHorizontal, RowsPerStrip: 8},
+		{Compression: CompressionDeflate, ByteOrder: binary.BigEndian, TileWidth: 16, TileLength: 16},
+	} {
+		f := &memFile{}
+		if err := NewEncoder(f, &opt).Encode16(data, 64, 32); err != nil {
+			t.Fatal(err)
+		}
+		tags, header, err := ReadTags(bytes.NewReader(f.buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// a complete file is read unchanged
+		got, lost, err := RecoverData16(bytes.NewReader(f.buf), header, tags, 0xffff)
+		if err != nil || lost != 0 || !reflect.DeepEqual(got, data) {
+			t.Errorf("%+v: complete file, lost %d rows, %v", opt, lost, err)
+		}
+
+		// cut the file off part way through the second row of strips or tiles
+		offsets, length := tags.StripOffsets, uint32(8)
+		if tags.tiled() {
+			offsets, length = tags.TileOffsets, 16
+		}
+		last := len(offsets) - 1
+		cut := f.buf[:offsets[last]+1]
+		if _, err := ReadData16(bytes.NewReader(cut), header, tags); !errors.Is(err, ErrTruncated) {
+			t.Errorf("%+v: expected ErrTruncated, got %v", opt, err)
+		}
+		got, lost, err = RecoverData16(bytes.NewReader(cut), header, tags, 0xffff)
+		if err != nil {
+			t.Fatalf("%+v: %v", opt, err)
+		}
+		if lost != length {
+			t.Errorf("%+v: expected %d lost rows, got %d", opt, length, lost)
+		}
+		l, err := newLayout(tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, y, w, h := l.rect(last)
+		for row := uint32(0); row < 32; row++ {
+			for col := uint32(0); col < 64; col++ {
+				i := row*64 + col
+				want := data[i]
+				if col >= x && col < x+w && row >= y && row < y+h {
+					want = 0xffff
+				}
+				if got[i] != want {
+					t.Fatalf("%+v: sample %d,%d expected %d, got %d", opt, col, row, want, got[i])
+				}
+			}
+		}
+	}
+
+	// the tags of a truncated uncompressed file, written with the IFD first, are read in every mode but ModeStrict
+	var buf bytes.Buffer
+	if err := NewForwardEncoder(&buf, &Options{RowsPerStrip: 8}).Encode16(data, 64, 32); err != nil {
+		t.Fatal(err)
+	}
+	cut := buf.Bytes()[:buf.Len()-1]
+	if _, _, err := ReadTagsOptions(bytes.NewReader(cut), &ReadOptions{Mode: ModeStrict}); !errors.Is(err, ErrTruncated) {
+		t.Errorf("expected ErrTruncated, got %v", err)
+	}
+	for _, mode := range []Mode{ModeDefault, ModeLenient} {
+		var warnings []error
+		tags, header, err := ReadTagsOptions(bytes.NewReader(cut), &ReadOptions{Mode: mode, Warn: func(err error) { warnings = append(warnings, err) }})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(warnings) != 1 || !errors.Is(warnings[0], ErrTruncated) {
+			t.Errorf("mode %d: expected a warning of ErrTruncated, got %v", mode, warnings)
+		}
+		if _, lost, err := RecoverData16(bytes.NewReader(cut), header, tags, 0); err != nil || lost != 8 {
+			t.Errorf("mode %d: expected 8 lost rows, got %d %v", mode, lost, err)
+		}
+	}
+
+	// errors that affect every block are still returned
+	f := &memFile{}
+	if err := NewEncoder(f, &Options{Compression: CompressionLZW}).Encode16(data, 64, 32); err != nil {
+		t.Fatal(err)
+	}
+	tags, header, err := ReadTags(bytes.NewReader(f.buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags.Compression = 7
+	if _, _, err := RecoverData16(bytes.NewReader(f.buf), header, tags, 0); !errors.Is(err, ErrUnsupportedCompression) {
+		t.Errorf("expected ErrUnsupportedCompression, got %v", err)
+	}
+	if _, _, err := RecoverData8(bytes.NewReader(f.buf), header, tags, 0); !errors.Is(err, ErrWrongBitsPerSample) {
+		t.Errorf("expected ErrWrongBitsPerSample, got %v", err)
+	}
+}
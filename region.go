@@ -0,0 +1,275 @@
+package gtiff
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ReadRegion8 reads the width x length region with its top left corner at x, y of an 8 bit tiff image into a 1d slice.
+// Only the strips or tiles overlapping the region are read and decoded.
+func ReadRegion8(r io.ReadSeeker, h Header, t Tags, x, y, width, length uint32) ([]uint8, error) {
+	return readRegion8(readerAt(r), h, t, region{x, y, width, length}, 1)
+}
+
+// ReadRegion16 reads the width x length region with its top left corner at x, y of a 16 bit tiff image into a 1d slice.
+// Only the strips or tiles overlapping the region are read and decoded.
+func ReadRegion16(r io.ReadSeeker, h Header, t Tags, x, y, width, length uint32) ([]uint16, error) {
+	return readRegion16(readerAt(r), h, t, region{x, y, width, length}, 1)
+}
+
+// ReadRegion32 reads the width x length region with its top left corner at x, y of a 32 bit float tiff image into a 1d slice.
+// Only the strips or tiles overlapping the region are read and decoded.
+func ReadRegion32(r io.ReadSeeker, h Header, t Tags, x, y, width, length uint32) ([]float32, error) {
+	return readRegion32(readerAt(r), h, t, region{x, y, width, length}, 1)
+}
+
+// read reg of an 8 bit image using concurrency goroutines
+func readRegion8(r io.ReaderAt, h Header, t Tags, reg region, concurrency int) ([]uint8, error) {
+	if _, err := regionLayout(t, 8, reg); err != nil {
+		return nil, err
+	}
+	data := make([]uint8, uint64(reg.width)*uint64(reg.length))
+	if err := readInto8(r, h, t, reg, concurrency, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// read reg of a 16 bit image using concurrency goroutines
+func readRegion16(r io.ReaderAt, h Header, t Tags, reg region, concurrency int) ([]uint16, error) {
+	if _, err := regionLayout(t, 16, reg); err != nil {
+		return nil, err
+	}
+	data := make([]uint16, uint64(reg.width)*uint64(reg.length))
+	if err := readInto16(r, h, t, reg, concurrency, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// read reg of a 32 bit float image using concurrency goroutines
+func readRegion32(r io.ReaderAt, h Header, t Tags, reg region, concurrency int) ([]float32, error) {
+	if _, err := regionLayout(t, 32, reg); err != nil {
+		return nil, err
+	}
+	data := make([]float32, uint64(reg.width)*uint64(reg.length))
+	if err := readInto32(r, h, t, reg, concurrency, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// read reg of an 8 bit image into the start of data using concurrency goroutines
+func readInto8(r io.ReaderAt, h Header, t Tags, reg region, concurrency int, data []uint8) error {
+	n, err := regionSamples(reg, len(data))
+	if err != nil {
+		return err
+	}
+	return readRegionInto(r, h, t, 8, reg, concurrency, data[:n])
+}
+
+// read reg of a 16 bit image into the start of data using concurrency goroutines, samples are decoded
+// straight into data and converted in place if the file is not in native byte order
+func readInto16(r io.ReaderAt, h Header, t Tags, reg region, concurrency int, data []uint16) error {
+	n, err := regionSamples(reg, len(data))
+	if err != nil {
+		return err
+	}
+	if err := readRegionInto(r, h, t, 16, reg, concurrency, uint16Bytes(data[:n])); err != nil {
+		return err
+	}
+	fromByteOrder16(data[:n], h.ByteOrder)
+	return nil
+}
+
+// read reg of a 32 bit float image into the start of data using concurrency goroutines, samples are decoded
+// straight into data and converted in place if the file is not in native byte order
+func readInto32(r io.ReaderAt, h Header, t Tags, reg region, concurrency int, data []float32) error {
+	n, err := regionSamples(reg, len(data))
+	if err != nil {
+		return err
+	}
+	if err := readRegionInto(r, h, t, 32, reg, concurrency, float32Bytes(data[:n])); err != nil {
+		return err
+	}
+	fromByteOrder32(data[:n], h.ByteOrder)
+	return nil
+}
+
+// number of samples in reg, checking a slice of size samples can hold them
+func regionSamples(reg region, size int) (uint64, error) {
+	n := uint64(reg.width) * uint64(reg.length)
+	if uint64(size) < n {
+		return 0, fmt.Errorf("read: %w, %d samples do not fit in slice of %d", io.ErrShortBuffer, n, size)
+	}
+	return n, nil
+}
+
+// read reg of an image into out in the byte order of the file using concurrency goroutines
+func readRegionInto(r io.ReaderAt, h Header, t Tags, bitsPerSample uint16, reg region, concurrency int, out []byte) error {
+	l, err := regionLayout(t, bitsPerSample, reg)
+	if err != nil {
+		return err
+	}
+	if n := uint64(reg.width) * uint64(reg.length) * uint64(l.bytesPerSample); uint64(len(out)) != n {
+		return fmt.Errorf("read: region needs %d bytes, got %d", n, len(out))
+	}
+	return decodeRegionParallel(r, h, t, l, reg, out, concurrency)
+}
+
+// layout of an image of bitsPerSample bits, checking reg is inside it
+func regionLayout(t Tags, bitsPerSample uint16, reg region) (layout, error) {
+	if t.BitsPerSample != bitsPerSample {
+		return layout{}, fmt.Errorf("read: %w, expected %d, got %d", ErrWrongBitsPerSample, bitsPerSample, t.BitsPerSample)
+	}
+	l, err := readLayout(t)
+	if err != nil {
+		return l, err
+	}
+	if uint64(reg.x)+uint64(reg.width) > uint64(l.width) || uint64(reg.y)+uint64(reg.length) > uint64(l.length) {
+		return l, fmt.Errorf("read: region %dx%d at %d,%d %w of %dx%d image", reg.width, reg.length, reg.x, reg.y, ErrOutOfRange, l.width, l.length)
+	}
+	return l, nil
+}
+
+// decode reg into out, which must hold all samples of reg. block is scratch space for decoding
+// a strip or tile, it is grown as needed and returned for reuse.
+func decodeRegion(r io.ReaderAt, h Header, t Tags, l layout, reg region, out, block []byte) ([]byte, error) {
+	if reg.width == 0 || reg.length == 0 {
+		return block, nil
+	}
+
+	var err error
+	x0, x1, y0, y1 := l.blockRange(reg)
+	for by := y0; by <= y1; by++ {
+		for bx := x0; bx <= x1; bx++ {
+			if block, err = decodeBlock(r, h, t, l, int(by*l.across+bx), reg, out, block); err != nil {
+				return block, err
+			}
+		}
+	}
+	return block, nil
+}
+
+// decode reg into out like decodeRegion, spreading the strips or tiles over concurrency goroutines.
+// Each block is decoded or copied straight to its own part of out so no locking of out is needed.
+func decodeRegionParallel(r io.ReaderAt, h Header, t Tags, l layout, reg region, out []byte, concurrency int) error {
+	if reg.width == 0 || reg.length == 0 {
+		return nil
+	}
+	x0, x1, y0, y1 := l.blockRange(reg)
+	if n := int((x1 - x0 + 1) * (y1 - y0 + 1)); concurrency > n {
+		concurrency = n
+	}
+	if concurrency <= 1 {
+		block := getScratch(0)
+		var err error
+		*block, err = decodeRegion(r, h, t, l, reg, out, *block)
+		scratchPool.Put(block)
+		return err
+	}
+	return decodeBlocksParallel(r, h, t, l, l.blocksIn(reg), reg, out, concurrency)
+}
+
+// decode blocks, which overlap reg, into out using concurrency goroutines. Kept apart from
+// decodeRegionParallel as the arguments captured by the goroutines escape to the heap.
+func decodeBlocksParallel(r io.ReaderAt, h Header, t Tags, l layout, blocks []int, reg region, out []byte, concurrency int) error {
+	next := make(chan int)
+	errs := make(chan error, concurrency)
+	for w := 0; w < concurrency; w++ {
+		go func() {
+			var block []byte
+			var err error
+			for i := range next {
+				if err == nil {
+					block, err = decodeBlock(r, h, t, l, i, reg, out, block)
+				}
+			}
+			errs <- err
+		}()
+	}
+
+	var failed error
+	for _, i := range blocks {
+		next <- i
+	}
+	close(next)
+	for w := 0; w < concurrency; w++ {
+		if err := <-errs; err != nil && failed == nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
+// scratch space for decoding strips or tiles, reused between reads so reading many images does not allocate
+var scratchPool = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+// scratch space of n bytes from scratchPool, it should be put back when no longer used
+func getScratch(n int) *[]byte {
+	b := scratchPool.Get().(*[]byte)
+	if cap(*b) < n {
+		*b = make([]byte, n)
+	}
+	*b = (*b)[:n]
+	return b
+}
+
+// decode the part of block i inside reg into out, block is scratch space as for decodeRegion.
+// Sparse blocks are filled with the nodata value.
+func decodeBlock(r io.ReaderAt, h Header, t Tags, l layout, i int, reg region, out, block []byte) ([]byte, error) {
+	if l.sparse(i) {
+		fillSparse(h, t, l, i, reg, out)
+		return block, nil
+	}
+	if (t.Compression == 0 || t.Compression == CompressionNone) && (t.Predictor == 0 || t.Predictor == PredictorNone) {
+		// samples can be read straight from the file
+		return block, readRawRegion(r, l, i, reg, out)
+	}
+	if _, by, _, bh := l.rect(i); !l.tiled && reg.x == 0 && reg.width == l.width && by >= reg.y && by+bh <= reg.y+reg.length {
+		// a strip of whole rows of reg is decoded straight into its part of out
+		start := uint64(by-reg.y) * uint64(reg.width) * uint64(l.bytesPerSample)
+		return block, readBlock(r, h, t, l, i, out[start:start+uint64(l.blockBytes(i))])
+	}
+
+	n := l.blockBytes(i)
+	if cap(block) < n {
+		block = make([]byte, n)
+	}
+	block = block[:n]
+	if err := readBlock(r, h, t, l, i, block); err != nil {
+		return block, err
+	}
+	l.copyToRegion(out, block, i, reg)
+	return block, nil
+}
+
+// read the part of uncompressed block i inside reg into out, which holds the samples of reg
+func readRawRegion(r io.ReaderAt, l layout, i int, reg region, out []byte) error {
+	bx, by, _, _ := l.rect(i)
+	in := l.intersect(i, reg)
+	rowBytes := uint64(in.width) * uint64(l.bytesPerSample)
+	blockRowBytes := uint64(l.blockWidth) * uint64(l.bytesPerSample)
+	outRowBytes := uint64(reg.width) * uint64(l.bytesPerSample)
+
+	// read a row at a time unless whole rows of the block are contiguous in both the file and out
+	rowsPerRead, reads := uint32(1), in.length
+	if in.width == l.blockWidth && in.width == reg.width {
+		rowsPerRead, reads = in.length, 1
+	}
+
+	for j := uint32(0); j < reads; j++ {
+		row := in.y + j*rowsPerRead
+		src := uint64(row-by)*blockRowBytes + uint64(in.x-bx)*uint64(l.bytesPerSample)
+		n := uint64(rowsPerRead) * rowBytes
+		if src+n > l.counts[i] {
+			return l.blockError(i, fmt.Errorf("%w, expected at least %d bytes, got %d", ErrTruncated, src+n, l.counts[i]))
+		}
+		dst := uint64(row-reg.y)*outRowBytes + uint64(in.x-reg.x)*uint64(l.bytesPerSample)
+		if _, err := readFullAt(r, out[dst:dst+n], int64(l.offsets[i]+src)); err != nil {
+			return l.blockError(i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package gtiff
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestReadRegion(t *testing.T) {
+	const width, length = 70, 45
+	data := make([]uint16, width*length)
+	for i := range data {
+		data[i] = uint16(i)
+	}
+
+	regions := []region{
+		{0, 0, width, length},
+		{0, 0, 1, 1},
+		{69, 44, 1, 1},
+		{10, 3, 20, 30},
+		{0, 17, width, 5},
+		{33, 0, 37, 45},
+		{5, 5, 0, 0},
+	}
+
+	for _, opt := range []Options{
+		{RowsPerStrip: 4},
+		{Compression: CompressionLZW, Predictor: PredictorHorizontal, RowsPerStrip: 7},
+		{TileWidth: 16, TileLength: 16},
+		{Compression: CompressionDeflate, TileWidth: 32, TileLength: 16},
+	} {
+		f := &memFile{}
+		if err := NewEncoder(f, &opt).Encode16(data, width, length); err != nil {
+			t.Fatal(err)
+		}
+		f.Seek(0, io.SeekStart)
+		tags, header, err := ReadTags(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, reg := range regions {
+			got, err := ReadRegion16(f, header, tags, reg.x, reg.y, reg.width, reg.length)
+			if err != nil {
+				t.Fatalf("%+v, %+v: %v", opt, reg, err)
+			}
+			expected := make([]uint16, 0, reg.width*reg.length)
+			for y := reg.y; y < reg.y+reg.length; y++ {
+				expected = append(expected, data[y*width+reg.x:y*width+reg.x+reg.width]...)
+			}
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("%+v, %+v: expected %v, got %v", opt, reg, expected, got)
+			}
+		}
+
+		if _, err := ReadRegion16(f, header, tags, 60, 0, 11, 1); err == nil {
+			t.Errorf("%+v: expected error for region outside image", opt)
+		}
+	}
+}
+
+func TestReadRegionReadsOverlappingBlocks(t *testing.T) {
+	const width, length = 1000, 1000
+	f := &memFile{}
+	opt := Options{Compression: CompressionDeflate, TileWidth: 64, TileLength: 64}
+	data := make([]uint8, width*length)
+	for i := range data {
+		data[i] = uint8(i * 7)
+	}
+	if err := NewEncoder(f, &opt).Encode8(data, width, length); err != nil {
+		t.Fatal(err)
+	}
+	f.Seek(0, io.SeekStart)
+	tags, header, err := ReadTags(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// region inside 2 tiles
+	r := &countingReader{ReadSeeker: f}
+	if _, err := ReadRegion8(r, header, tags, 500, 520, 50, 4); err != nil {
+		t.Fatal(err)
+	}
+	max := tags.TileByteCounts[8*16+7] + tags.TileByteCounts[8*16+8]
+	if r.n > int(max) {
+		t.Errorf("expected at most %d bytes read, got %d", max, r.n)
+	}
+}
+
+func TestReadRegionIntegration8(t *testing.T) {
+	r, err := os.Open("./test-images/cell8.tif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	tags, header, err := ReadTags(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// last 10 pixels of the image
+	data8, err := ReadRegion8(r, header, tags, 181, 158, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected8 := []uint8{117, 119, 118, 117, 118, 119, 119, 119, 118, 122}
+	if !reflect.DeepEqual(expected8, data8) {
+		t.Errorf("expected %v, got %v", expected8, data8)
+	}
+}
+
+// countingReader counts the bytes read from an io.ReadSeeker
+type countingReader struct {
+	io.ReadSeeker
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadSeeker.Read(p)
+	r.n += n
+	return n, err
+}
+
+func TestDecodeStripInPlace(t *testing.T) {
+	const width, length = 30, 20
+	data := make([]uint16, width*length)
+	for i := range data {
+		data[i] = uint16(i)
+	}
+	f := &memFile{}
+	if err := NewEncoder(f, &Options{Compression: CompressionLZW, Predictor: PredictorHorizontal, RowsPerStrip: 6}).Encode16(data, width, length); err != nil {
+		t.Fatal(err)
+	}
+	f.Seek(0, io.SeekStart)
+	tags, header, err := ReadTags(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := readLayout(tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// strips inside the region need no scratch space, those cut by it do
+	for _, c := range []struct {
+		reg     region
+		scratch bool
+	}{
+		{region{0, 0, width, length}, false},
+		{region{0, 6, width, 6}, false},
+		{region{0, 2, width, 6}, true},
+		{region{1, 6, 29, 6}, true},
+	} {
+		out := make([]byte, 2*c.reg.width*c.reg.length)
+		block, err := decodeBlock(readerAt(f), header, tags, l, 1, c.reg, out, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (block != nil) != c.scratch {
+			t.Errorf("%+v: expected scratch %v, got %d bytes", c.reg, c.scratch, len(block))
+		}
+	}
+
+	got, err := ReadData16(f, header, tags)
+	if err != nil || !reflect.DeepEqual(got, data) {
+		t.Errorf("expected data unchanged, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package gtiff
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Resolution units (tag 296).
+const (
+	ResolutionUnitNone       uint16 = 1 // no absolute unit, resolution only gives the aspect ratio
+	ResolutionUnitInch       uint16 = 2 // default per tiff 6.0 spec
+	ResolutionUnitCentimeter uint16 = 3
+)
+
+// MicronsPerUnit returns the number of micrometres in one resolution unit.
+func MicronsPerUnit(unit uint16) (float64, error) {
+	switch unit {
+	case ResolutionUnitInch:
+		return 25400, nil
+	case ResolutionUnitCentimeter:
+		return 10000, nil
+	case ResolutionUnitNone:
+		return 0, errors.New("resolution: unit has no absolute size")
+	}
+	return 0, fmt.Errorf("resolution: unknown unit %d", unit)
+}
+
+// PixelSize returns the width and height of a pixel in the returned ResolutionUnit.
+// Sizes are 0 if the resolution is not set.
+func (t Tags) PixelSize() (float64, float64, uint16) {
+	unit := t.ResolutionUnit
+	if unit == 0 {
+		unit = ResolutionUnitInch // default when tag is absent
+	}
+	return rationalInverse(t.XResolution), rationalInverse(t.YResolution), unit
+}
+
+// PixelSizeMicrons returns the width and height of a pixel in micrometres.
+func (t Tags) PixelSizeMicrons() (float64, float64, error) {
+	x, y, unit := t.PixelSize()
+	if x == 0 || y == 0 {
+		return 0, 0, errors.New("resolution: not set")
+	}
+	um, err := MicronsPerUnit(unit)
+	if err != nil {
+		return 0, 0, err
+	}
+	return x * um, y * um, nil
+}
+
+// SetPixelSize sets XResolution and YResolution so a pixel is microns wide and high.
+// Resolution is recorded in pixels per centimetre.
+func (t *Tags) SetPixelSize(microns float64) {
+	t.SetPixelSizeXY(microns, microns)
+}
+
+// SetPixelSizeXY sets XResolution and YResolution so a pixel is x microns wide and y microns high.
+// Resolution is recorded in pixels per centimetre.
+func (t *Tags) SetPixelSizeXY(x, y float64) {
+	um, _ := MicronsPerUnit(ResolutionUnitCentimeter)
+	xNum, xDen := floatToRational(um / x)
+	yNum, yDen := floatToRational(um / y)
+	t.XResolution = []uint32{xNum, xDen}
+	t.YResolution = []uint32{yNum, yDen}
+	t.ResolutionUnit = ResolutionUnitCentimeter
+}
+
+// resolution values to write, defaults to an aspect ratio of 1 with no unit
+func (t Tags) resolution() ([]uint32, []uint32, uint16) {
+	x, y, unit := t.XResolution, t.YResolution, t.ResolutionUnit
+	if !validRational(x) || !validRational(y) {
+		return []uint32{1, 1}, []uint32{1, 1}, ResolutionUnitNone
+	}
+	if unit == 0 {
+		unit = ResolutionUnitInch
+	}
+	return x, y, unit
+}
+
+// a rational is valid if it has a numerator and non zero denominator
+func validRational(r []uint32) bool {
+	return len(r) == 2 && r[0] != 0 && r[1] != 0
+}
+
+// returns 1/r for a numerator, denominator pair, 0 if r is not valid
+func rationalInverse(r []uint32) float64 {
+	if !validRational(r) {
+		return 0
+	}
+	return float64(r[1]) / float64(r[0])
+}
+
+// approximate v as numerator/denominator keeping as many decimal places as fit in 32 bits
+func floatToRational(v float64) (uint32, uint32) {
+	if math.IsNaN(v) || v <= 0 {
+		return 0, 1
+	}
+	den := uint32(1)
+	for den < 1000000 && v*float64(den)*10 <= math.MaxUint32 {
+		den *= 10
+	}
+	if v*float64(den) > math.MaxUint32 {
+		return math.MaxUint32, 1
+	}
+	return uint32(math.Round(v * float64(den))), den
+}
@@ -0,0 +1,53 @@
+package gtiff
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPixelSize(t *testing.T) {
+	tests := []struct {
+		res    []uint32
+		unit   uint16
+		micron float64
+	}{
+		{[]uint32{7200000, 100000}, ResolutionUnitInch, 25400.0 / 72},
+		{[]uint32{15384615, 1000}, ResolutionUnitCentimeter, 0.65},
+		{[]uint32{300, 1}, 0, 25400.0 / 300}, // inch is the default unit
+	}
+
+	for _, test := range tests {
+		tags := Tags{XResolution: test.res, YResolution: test.res, ResolutionUnit: test.unit}
+		x, y, err := tags.PixelSizeMicrons()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(x-test.micron) > 1e-6 || math.Abs(y-test.micron) > 1e-6 {
+			t.Errorf("expected %v, got %v %v", test.micron, x, y)
+		}
+	}
+}
+
+func TestPixelSizeErrors(t *testing.T) {
+	if _, _, err := (Tags{}).PixelSizeMicrons(); err == nil {
+		t.Errorf("expected error for unset resolution")
+	}
+	tags := Tags{XResolution: []uint32{1, 1}, YResolution: []uint32{1, 1}, ResolutionUnit: ResolutionUnitNone}
+	if _, _, err := tags.PixelSizeMicrons(); err == nil {
+		t.Errorf("expected error for resolution without unit")
+	}
+}
+
+func TestSetPixelSize(t *testing.T) {
+	for _, um := range []float64{0.001, 0.1083, 0.65, 1, 6.5, 250, 1e6} {
+		var tags Tags
+		tags.SetPixelSizeXY(um, 2*um)
+		x, y, err := tags.PixelSizeMicrons()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(x-um)/um > 1e-6 || math.Abs(y-2*um)/um > 1e-6 {
+			t.Errorf("expected %v %v, got %v %v", um, 2*um, x, y)
+		}
+	}
+}
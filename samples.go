@@ -0,0 +1,249 @@
+package gtiff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"reflect"
+	"unsafe"
+)
+
+// byte order of the machine, samples in this order are used as read without conversion
+var nativeEndian binary.ByteOrder = func() binary.ByteOrder {
+	v := uint16(1)
+	if *(*byte)(unsafe.Pointer(&v)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+// memory of s viewed as bytes, so samples can be decoded straight into s
+func uint16Bytes(s []uint16) []byte {
+	var b []byte
+	if len(s) == 0 {
+		return b
+	}
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&b))
+	h.Data = uintptr(unsafe.Pointer(&s[0]))
+	h.Len = 2 * len(s)
+	h.Cap = 2 * len(s)
+	return b
+}
+
+// memory of s viewed as bytes, so samples can be decoded straight into s
+func float32Bytes(s []float32) []byte {
+	var b []byte
+	if len(s) == 0 {
+		return b
+	}
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&b))
+	h.Data = uintptr(unsafe.Pointer(&s[0]))
+	h.Len = 4 * len(s)
+	h.Cap = 4 * len(s)
+	return b
+}
+
+// memory of s viewed as signed samples of the same size
+func int8s(s []uint8) []int8 {
+	var v []int8
+	if len(s) == 0 {
+		return v
+	}
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&v))
+	h.Data = uintptr(unsafe.Pointer(&s[0]))
+	h.Len = len(s)
+	h.Cap = len(s)
+	return v
+}
+
+// memory of s viewed as signed samples of the same size
+func int16s(s []uint16) []int16 {
+	var v []int16
+	if len(s) == 0 {
+		return v
+	}
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&v))
+	h.Data = uintptr(unsafe.Pointer(&s[0]))
+	h.Len = len(s)
+	h.Cap = len(s)
+	return v
+}
+
+// memory of s, read as float32 samples, viewed as the unsigned integers it holds
+func uint32s(s []float32) []uint32 {
+	var v []uint32
+	if len(s) == 0 {
+		return v
+	}
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&v))
+	h.Data = uintptr(unsafe.Pointer(&s[0]))
+	h.Len = len(s)
+	h.Cap = len(s)
+	return v
+}
+
+// memory of s, read as float32 samples, viewed as the signed integers it holds
+func int32s(s []float32) []int32 {
+	var v []int32
+	if len(s) == 0 {
+		return v
+	}
+	h := (*reflect.SliceHeader)(unsafe.Pointer(&v))
+	h.Data = uintptr(unsafe.Pointer(&s[0]))
+	h.Len = len(s)
+	h.Cap = len(s)
+	return v
+}
+
+// convert samples decoded into the memory of s in byteOrder to native values in place,
+// the standard byte orders only need the bytes of each sample swapped
+func fromByteOrder16(s []uint16, byteOrder binary.ByteOrder) {
+	switch byteOrder {
+	case nativeEndian:
+	case binary.LittleEndian, binary.BigEndian:
+		for i, v := range s {
+			s[i] = bits.ReverseBytes16(v)
+		}
+	default:
+		decode16(s, uint16Bytes(s), byteOrder)
+	}
+}
+
+// convert samples decoded into the memory of s in byteOrder to native values in place,
+// the standard byte orders only need the bytes of each sample swapped
+func fromByteOrder32(s []float32, byteOrder binary.ByteOrder) {
+	switch byteOrder {
+	case nativeEndian:
+	case binary.LittleEndian, binary.BigEndian:
+		for i, v := range s {
+			s[i] = math.Float32frombits(bits.ReverseBytes32(math.Float32bits(v)))
+		}
+	default:
+		decode32(s, float32Bytes(s), byteOrder)
+	}
+}
+
+// convert samples in byteOrder in b to values in dst, which must hold len(b)/2 samples
+func decode16(dst []uint16, b []byte, byteOrder binary.ByteOrder) {
+	// the standard byte orders are called directly so the conversions are inlined
+	switch byteOrder {
+	case binary.LittleEndian:
+		for i := range dst {
+			dst[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+	case binary.BigEndian:
+		for i := range dst {
+			dst[i] = binary.BigEndian.Uint16(b[2*i:])
+		}
+	default:
+		for i := range dst {
+			dst[i] = byteOrder.Uint16(b[2*i:])
+		}
+	}
+}
+
+// convert samples in byteOrder in b to values in dst, which must hold len(b)/4 samples
+func decode32(dst []float32, b []byte, byteOrder binary.ByteOrder) {
+	// the standard byte orders are called directly so the conversions are inlined
+	switch byteOrder {
+	case binary.LittleEndian:
+		for i := range dst {
+			dst[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+		}
+	case binary.BigEndian:
+		for i := range dst {
+			dst[i] = math.Float32frombits(binary.BigEndian.Uint32(b[4*i:]))
+		}
+	default:
+		for i := range dst {
+			dst[i] = math.Float32frombits(byteOrder.Uint32(b[4*i:]))
+		}
+	}
+}
+
+// convert samples in s to bytes in byteOrder in dst, which must hold 2*len(s) bytes
+func encode16(dst []byte, s []uint16, byteOrder binary.ByteOrder) {
+	// the standard byte orders are called directly so the conversions are inlined
+	switch byteOrder {
+	case binary.LittleEndian:
+		for i, v := range s {
+			binary.LittleEndian.PutUint16(dst[2*i:], v)
+		}
+	case binary.BigEndian:
+		for i, v := range s {
+			binary.BigEndian.PutUint16(dst[2*i:], v)
+		}
+	default:
+		for i, v := range s {
+			byteOrder.PutUint16(dst[2*i:], v)
+		}
+	}
+}
+
+// convert samples in s to bytes in byteOrder in dst, which must hold 4*len(s) bytes
+func encode32(dst []byte, s []float32, byteOrder binary.ByteOrder) {
+	// the standard byte orders are called directly so the conversions are inlined
+	switch byteOrder {
+	case binary.LittleEndian:
+		for i, v := range s {
+			binary.LittleEndian.PutUint32(dst[4*i:], math.Float32bits(v))
+		}
+	case binary.BigEndian:
+		for i, v := range s {
+			binary.BigEndian.PutUint32(dst[4*i:], math.Float32bits(v))
+		}
+	default:
+		for i, v := range s {
+			byteOrder.PutUint32(dst[4*i:], math.Float32bits(v))
+		}
+	}
+}
+
+// first n samples of data, a []uint8, []uint16 or []float32, as bytes in byteOrder. In native byte order
+// the memory of data itself is returned, otherwise a converted copy.
+func sampleBytes(data interface{}, n uint64, byteOrder binary.ByteOrder) ([]byte, error) {
+	if err := checkDataSize(data, n); err != nil {
+		return nil, err
+	}
+	switch d := data.(type) {
+	case []uint8:
+		return d[:n], nil
+	case []uint16:
+		if byteOrder == nativeEndian {
+			return uint16Bytes(d[:n]), nil
+		}
+		b := make([]byte, 2*n)
+		encode16(b, d[:n], byteOrder)
+		return b, nil
+	case []float32:
+		if byteOrder == nativeEndian {
+			return float32Bytes(d[:n]), nil
+		}
+		b := make([]byte, 4*n)
+		encode32(b, d[:n], byteOrder)
+		return b, nil
+	}
+	return nil, fmt.Errorf("write: unsupported data type %T", data)
+}
+
+// check data, a []uint8, []uint16 or []float32, holds exactly n samples
+func checkDataSize(data interface{}, n uint64) error {
+	if l := uint64(sampleCount(data)); l != n {
+		return fmt.Errorf("write: %w, %d samples of data, expected width*length = %d", ErrDataSize, l, n)
+	}
+	return nil
+}
+
+// number of samples in data, a []uint8, []uint16 or []float32
+func sampleCount(data interface{}) int {
+	switch d := data.(type) {
+	case []uint8:
+		return len(d)
+	case []uint16:
+		return len(d)
+	case []float32:
+		return len(d)
+	}
+	return 0
+}
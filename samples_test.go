@@ -0,0 +1,87 @@
+package gtiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestFromByteOrder(t *testing.T) {
+	for _, byteOrder := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		s16 := make([]uint16, 3)
+		b := uint16Bytes(s16)
+		for i, v := range []uint16{1, 0x1234, 0xfffe} {
+			byteOrder.PutUint16(b[2*i:], v)
+		}
+		fromByteOrder16(s16, byteOrder)
+		if s16[0] != 1 || s16[1] != 0x1234 || s16[2] != 0xfffe {
+			t.Errorf("%v: expected [1 4660 65534], got %v", byteOrder, s16)
+		}
+
+		s32 := make([]float32, 3)
+		b = float32Bytes(s32)
+		for i, v := range []float32{1.5, -2, float32(math.Inf(1))} {
+			byteOrder.PutUint32(b[4*i:], math.Float32bits(v))
+		}
+		fromByteOrder32(s32, byteOrder)
+		if s32[0] != 1.5 || s32[1] != -2 || !math.IsInf(float64(s32[2]), 1) {
+			t.Errorf("%v: expected [1.5 -2 +Inf], got %v", byteOrder, s32)
+		}
+	}
+
+	if len(uint16Bytes(nil)) != 0 || len(float32Bytes(nil)) != 0 {
+		t.Error("expected no bytes for empty slices")
+	}
+}
+
+// a large uncompressed image in a byte order
+func benchmarkImage(b *testing.B, byteOrder binary.ByteOrder, bitsPerSample int) []byte {
+	const width, length = 4096, 4096
+	f := &memFile{}
+	e := NewEncoder(f, &Options{ByteOrder: byteOrder})
+	var err error
+	if bitsPerSample == 16 {
+		err = e.Encode16(make([]uint16, width*length), width, length)
+	} else {
+		err = e.Encode32(make([]float32, width*length), width, length)
+	}
+	if err != nil {
+		b.Fatal(err)
+	}
+	return f.buf
+}
+
+func benchmarkReadData(b *testing.B, byteOrder binary.ByteOrder, bitsPerSample int) {
+	file := benchmarkImage(b, byteOrder, bitsPerSample)
+	r := bytes.NewReader(file)
+	tags, header, err := ReadTags(r)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(tags.ImageWidth) * int64(tags.ImageLength) * int64(bitsPerSample/8))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if bitsPerSample == 16 {
+			_, err = ReadData16(r, header, tags)
+		} else {
+			_, err = ReadData32(r, header, tags)
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadData16Native(b *testing.B)  { benchmarkReadData(b, nativeEndian, 16) }
+func BenchmarkReadData16Swapped(b *testing.B) { benchmarkReadData(b, swappedEndian(), 16) }
+func BenchmarkReadData32Native(b *testing.B)  { benchmarkReadData(b, nativeEndian, 32) }
+func BenchmarkReadData32Swapped(b *testing.B) { benchmarkReadData(b, swappedEndian(), 32) }
+
+// byte order that is not native
+func swappedEndian() binary.ByteOrder {
+	if nativeEndian == binary.LittleEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
@@ -0,0 +1,76 @@
+package gtiff
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// TagGDALNoData is the GDAL_NODATA tag, an ascii value given to pixels that hold no data.
+const TagGDALNoData = 42113
+
+// NoData parses the GDAL_NODATA tag, reporting false if it is missing or not a number.
+func (t Tags) NoData() (float64, bool) {
+	f, ok := t.Field(TagGDALNoData)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimRight(string(f.Value), "\x00")), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// reports if block i is sparse, stored with an offset or byte count of 0 as GDAL writes empty blocks
+func (l layout) sparse(i int) bool {
+	return l.offsets[i] == 0 || l.counts[i] == 0
+}
+
+// the nodata value of the image described by t as one sample in byte order, 0 if it has none.
+// Integer samples, including those without a SampleFormat, are clamped to the range of the sample format.
+func noDataSample(t Tags, byteOrder binary.ByteOrder) (sample [4]byte, n int) {
+	v, _ := t.NoData()
+	if math.IsNaN(v) && t.SampleFormat != 3 {
+		v = 0
+	}
+	signed := t.SampleFormat == 2
+	switch t.BitsPerSample {
+	case 8:
+		if signed {
+			sample[0] = uint8(int8(clamp(v, math.MinInt8, math.MaxInt8)))
+		} else {
+			sample[0] = uint8(clamp(v, 0, math.MaxUint8))
+		}
+		return sample, 1
+	case 16:
+		if signed {
+			byteOrder.PutUint16(sample[:], uint16(int16(clamp(v, math.MinInt16, math.MaxInt16))))
+		} else {
+			byteOrder.PutUint16(sample[:], uint16(clamp(v, 0, math.MaxUint16)))
+		}
+		return sample, 2
+	default:
+		switch t.SampleFormat {
+		case 3:
+			byteOrder.PutUint32(sample[:], math.Float32bits(float32(v)))
+		case 2:
+			byteOrder.PutUint32(sample[:], uint32(int32(clamp(v, math.MinInt32, math.MaxInt32))))
+		default:
+			byteOrder.PutUint32(sample[:], uint32(clamp(v, 0, math.MaxUint32)))
+		}
+		return sample, 4
+	}
+}
+
+// v rounded to the nearest integer in [min, max]
+func clamp(v, min, max float64) float64 {
+	return math.Max(min, math.Min(max, math.Round(v)))
+}
+
+// fill the part of sparse block i inside reg in out, which holds the samples of reg, with the nodata value
+func fillSparse(h Header, t Tags, l layout, i int, reg region, out []byte) {
+	sample, n := noDataSample(t, h.ByteOrder)
+	l.fillRegion(out, sample[:n], i, reg)
+}
@@ -0,0 +1,96 @@
+package gtiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestSparse(t *testing.T) {
+	// strip 1 has offset 0 and strip 2 a byte count of 0
+	sparse := buildTiff(func(d *ifdBuilder) {
+		d.long(256, 2)
+		d.long(257, 4)
+		d.short(258, 8)
+		d.short(262, 1)
+		d.long(273, 8, 0, 12, 14)
+		d.long(278, 1)
+		d.long(279, 2, 2, 0, 2)
+		d.ascii(TagGDALNoData, "255")
+	})
+	r := bytes.NewReader(sparse)
+	tags, header, err := ReadTagsOptions(r, &ReadOptions{Mode: ModeStrict})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := tags.NoData(); !ok || v != 255 {
+		t.Errorf("expected nodata 255, got %v %v", v, ok)
+	}
+	data, err := ReadData8(r, header, tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []uint8{1, 2, 255, 255, 255, 255, 7, 8}; !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %v, got %v", expected, data)
+	}
+	reader, err := NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block, err := reader.Block(0, 2); err != nil || !reflect.DeepEqual(block, []byte{255, 255}) {
+		t.Errorf("expected sparse block of nodata, got %v %v", block, err)
+	}
+
+	// byte counts of an uncompressed image follow from its size
+	noCounts := buildTiff(func(d *ifdBuilder) {
+		d.long(256, 2)
+		d.long(257, 4)
+		d.short(258, 8)
+		d.short(262, 1)
+		d.long(273, 8, 10, 12, 14)
+		d.long(278, 1)
+	})
+	r = bytes.NewReader(noCounts)
+	tags, header, err = ReadTags(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []uint64{2, 2, 2, 2}; !reflect.DeepEqual(tags.StripByteCounts, expected) {
+		t.Errorf("expected inferred byte counts %v, got %v", expected, tags.StripByteCounts)
+	}
+	if data, err := ReadData8(r, header, tags); err != nil || !reflect.DeepEqual(data, []uint8{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Errorf("expected pixels 1 to 8, got %v %v", data, err)
+	}
+	if _, _, err := ReadTagsOptions(bytes.NewReader(noCounts), &ReadOptions{Mode: ModeStrict}); err == nil {
+		t.Error("expected error for missing byte counts in ModeStrict")
+	}
+
+	// nodata is clamped to the sample format and written in the byte order of the file
+	for _, c := range []struct {
+		nodata        string
+		bitsPerSample uint16
+		sampleFormat  uint16
+		expected      []byte
+	}{
+		{"-9999", 16, 2, []byte{0xf1, 0xd8}},
+		{"-9999", 16, 1, []byte{0, 0}},
+		{"1e6", 8, 1, []byte{255}},
+		{"", 8, 1, []byte{0}},
+		{"-3.5", 32, 3, []byte{0, 0, 0x60, 0xc0}},
+		{"nan", 32, 3, []byte{0, 0, 0xc0, 0x7f}},
+		{"-9999", 32, 2, []byte{0xf1, 0xd8, 0xff, 0xff}},
+		{"nan", 32, 2, []byte{0, 0, 0, 0}},
+		{"-9999", 32, 1, []byte{0, 0, 0, 0}},
+		{"5e9", 32, 0, []byte{0xff, 0xff, 0xff, 0xff}},
+	} {
+		tags := Tags{BitsPerSample: c.bitsPerSample, SampleFormat: c.sampleFormat}
+		if c.nodata != "" {
+			tags.Extra = []Field{{Tag: TagGDALNoData, Type: 2, Count: uint64(len(c.nodata) + 1), Value: []byte(c.nodata + "\x00")}}
+		}
+		sample, n := noDataSample(tags, binary.LittleEndian)
+		if !bytes.Equal(sample[:n], c.expected) {
+			t.Errorf("nodata %q as %d bits: expected %v, got %v", c.nodata, c.bitsPerSample, c.expected, sample[:n])
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package gtiff
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// StreamWriter writes an image to a tiff a few rows at a time, so images larger than memory can be written.
+// Rows are buffered until a strip, or a row of tiles, is complete and then compressed and written,
+// so memory use is bounded by the size of one strip or row of tiles, or Options.Concurrency of them
+// compressed in parallel.
+// Close must be called after all rows are written to write the ifd and header.
+type StreamWriter struct {
+	w       io.WriteSeeker
+	buf     *bufio.Writer // strips or tiles are written through buf, small ones are written together
+	opt     Options
+	t       Tags // structural tags of the image
+	l       layout
+	band    []byte // rows of the strips or rows of tiles being filled
+	bands   uint32 // strips or rows of tiles buffered in band
+	n       int    // bytes of band filled
+	rows    uint32 // rows completed
+	block   []byte // strip or tile being compressed
+	blocks  []int  // indexes of the strips or tiles being compressed
+	convBuf []byte // samples converted to the byte order of the file
+	pos     uint64 // current position in file
+	offsets []uint64
+	counts  []uint64
+	err     error // first error, all later calls fail with it
+}
+
+// NewStreamWriter returns a StreamWriter for an image of width x length samples of bitsPerSample bits
+// (8 and 16 bit unsigned ints and 32 bit floats are supported). opt may be nil to use the defaults.
+// Options.Verify is not supported, as a StreamWriter does not keep the data written.
+func NewStreamWriter(w io.WriteSeeker, width, length uint32, bitsPerSample uint16, opt *Options) (*StreamWriter, error) {
+	var sampleFormat uint16 = 1
+	if bitsPerSample == 32 {
+		sampleFormat = 3
+	}
+	if opt == nil {
+		opt = &Options{}
+	}
+	if opt.Verify {
+		return nil, errors.New("write: Verify is not supported by a StreamWriter, which does not keep the data written")
+	}
+	return newStreamWriter(w, width, length, bitsPerSample, sampleFormat, *opt)
+}
+
+func newStreamWriter(w io.WriteSeeker, width, length uint32, bitsPerSample, sampleFormat uint16, opt Options) (*StreamWriter, error) {
+	if opt.ByteOrder == nil {
+		opt.ByteOrder = binary.LittleEndian
+	}
+	t, l, err := opt.structure(width, length, bitsPerSample, sampleFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	// skip header, it is written last once the offset of the ifd is known
+	headerBytes := opt.headerBytes()
+	if _, err := w.Seek(int64(headerBytes), 0); err != nil {
+		return nil, err
+	}
+
+	bands := uint32(1)
+	if opt.Concurrency > 1 {
+		// enough rows to give each goroutine a strip or row of tiles
+		bands = minUint32(uint32(opt.Concurrency), l.down)
+	}
+
+	return &StreamWriter{
+		w:       w,
+		opt:     opt,
+		t:       t,
+		l:       l,
+		band:    make([]byte, uint64(l.width)*uint64(l.blockLength)*uint64(bands)*uint64(l.bytesPerSample)),
+		bands:   bands,
+		buf:     bufio.NewWriterSize(w, writeBufferSize),
+		block:   make([]byte, l.blockBytes(0)),
+		pos:     headerBytes,
+		offsets: make([]uint64, 0, l.blocks()),
+		counts:  make([]uint64, 0, l.blocks()),
+	}, nil
+}
+
+// Write writes raw sample bytes in the byte order of the file, continuing from the last row written.
+// Rows may be split across calls.
+func (s *StreamWriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	written := 0
+	for len(p) > 0 {
+		if s.rows >= s.l.length {
+			s.err = errors.New("stream: write past end of image")
+			return written, s.err
+		}
+		n := copy(s.band[s.n:s.bandBytes()], p)
+		s.n += n
+		p = p[n:]
+		written += n
+		if s.n == s.bandBytes() {
+			if err := s.flush(); err != nil {
+				s.err = err
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Write8 writes rows of uint8 data.
+func (s *StreamWriter) Write8(data []uint8) error {
+	return s.writeData(data, uint64(len(data)), 8)
+}
+
+// Write16 writes rows of uint16 data.
+func (s *StreamWriter) Write16(data []uint16) error {
+	return s.writeData(data, uint64(len(data)), 16)
+}
+
+// Write32 writes rows of float32 data.
+func (s *StreamWriter) Write32(data []float32) error {
+	return s.writeData(data, uint64(len(data)), 32)
+}
+
+// Close writes the ifd and header. It fails if fewer rows than the image length have been written.
+// Close does not close the underlying writer.
+func (s *StreamWriter) Close() error {
+	// steps:
+	// 1) build 1 ifd with 1 directory entry for each structural tag followed by metadata tags
+	// 2) write the ifd at the next word boundry followed by values too large to fit in their directory entry
+	// 3) write header at offset 0 with offset of the ifd
+
+	if s.err != nil {
+		return s.err
+	}
+	if s.rows < s.l.length {
+		s.err = fmt.Errorf("stream: %d of %d rows written", s.rows+uint32(s.n)/(s.l.width*s.l.bytesPerSample), s.l.length)
+		return s.err
+	}
+	s.err = errors.New("stream: closed")
+
+	// 1)
+	d := s.opt.ifd(s.t, s.offsets, s.counts)
+	if err := s.opt.metadata(&d); err != nil {
+		return err
+	}
+
+	// 2)
+	if s.pos%2 == 1 {
+		if _, err := s.buf.Write([]byte{0}); err != nil {
+			return err
+		}
+		s.pos++
+	}
+	if !s.opt.BigTIFF && s.pos+d.size() > math.MaxUint32 {
+		return errors.New("write: file larger than 4 GiB requires BigTIFF")
+	}
+	if _, err := s.buf.Write(d.encode(s.pos)); err != nil {
+		return err
+	}
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+
+	// 3)
+	if _, err := s.w.Seek(0, 0); err != nil {
+		return err
+	}
+	return binary.Write(s.w, s.opt.ByteOrder, s.opt.header(s.pos))
+}
+
+// write the first n samples of data, a []uint8, []uint16 or []float32, in the byte order of the file.
+// Samples not in native byte order are converted a chunk at a time through a reused buffer.
+func (s *StreamWriter) writeData(data interface{}, n uint64, bitsPerSample uint16) error {
+	if s.t.BitsPerSample != bitsPerSample {
+		return fmt.Errorf("stream: %w, writing %d bit data to %d bit image", ErrWrongBitsPerSample, bitsPerSample, s.t.BitsPerSample)
+	}
+
+	switch d := data.(type) {
+	case []uint8:
+		_, err := s.Write(d[:n])
+		return err
+	case []uint16:
+		d = d[:n]
+		if s.opt.ByteOrder == nativeEndian {
+			_, err := s.Write(uint16Bytes(d))
+			return err
+		}
+		for len(d) > 0 {
+			c := minInt(len(d), len(s.conv())/2)
+			encode16(s.conv(), d[:c], s.opt.ByteOrder)
+			if _, err := s.Write(s.conv()[:2*c]); err != nil {
+				return err
+			}
+			d = d[c:]
+		}
+	case []float32:
+		d = d[:n]
+		if s.opt.ByteOrder == nativeEndian {
+			_, err := s.Write(float32Bytes(d))
+			return err
+		}
+		for len(d) > 0 {
+			c := minInt(len(d), len(s.conv())/4)
+			encode32(s.conv(), d[:c], s.opt.ByteOrder)
+			if _, err := s.Write(s.conv()[:4*c]); err != nil {
+				return err
+			}
+			d = d[c:]
+		}
+	}
+	return nil
+}
+
+// buffer for converting samples to the byte order of the file, allocated on first use
+func (s *StreamWriter) conv() []byte {
+	if s.convBuf == nil {
+		s.convBuf = make([]byte, writeBufferSize)
+	}
+	return s.convBuf
+}
+
+// size in bytes of the current bands, the last band is clipped to the image
+func (s *StreamWriter) bandBytes() int {
+	rows := s.l.blockLength * s.bands
+	if s.rows+rows > s.l.length {
+		rows = s.l.length - s.rows
+	}
+	return int(rows * s.l.width * s.l.bytesPerSample)
+}
+
+// compress and write all strips or tiles in the full bands
+func (s *StreamWriter) flush() error {
+	// blocks in these bands
+	rows := uint32(s.n) / (s.l.width * s.l.bytesPerSample)
+	first := int(s.rows/s.l.blockLength) * int(s.l.across)
+	last := int((s.rows+rows+s.l.blockLength-1)/s.l.blockLength) * int(s.l.across)
+	s.blocks = s.blocks[:0]
+	for i := first; i < last; i++ {
+		s.blocks = append(s.blocks, i)
+	}
+
+	fill := func(b []byte, i int) { s.l.copyFromImage(b, s.band, s.rows, i) }
+	write := func(i int, c []byte) error {
+		if _, err := s.buf.Write(c); err != nil {
+			return err
+		}
+		s.offsets = append(s.offsets, s.pos)
+		s.counts = append(s.counts, uint64(len(c)))
+		s.pos += uint64(len(c))
+		return nil
+	}
+	if err := s.opt.encodeBlocks(s.l, s.blocks, s.block, fill, write); err != nil {
+		return err
+	}
+
+	s.rows += rows
+	s.n = 0
+	return nil
+}
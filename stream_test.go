@@ -0,0 +1,98 @@
+package gtiff
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestStreamWriter(t *testing.T) {
+	const width, length = 45, 70
+	data := make([]uint16, width*length)
+	for i := range data {
+		data[i] = uint16(i * 3)
+	}
+
+	for _, opt := range []Options{
+		{RowsPerStrip: 8},
+		{Compression: CompressionLZW, Predictor: PredictorHorizontal, StripSize: 1000},
+		{Compression: CompressionDeflate, TileWidth: 32, TileLength: 16},
+		{TileWidth: 16, TileLength: 16, BigTIFF: true},
+	} {
+		f := &memFile{}
+		s, err := NewStreamWriter(f, width, length, 16, &opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// write in chunks that don't line up with rows or strips
+		for i := 0; i < len(data); i += 101 {
+			end := i + 101
+			if end > len(data) {
+				end = len(data)
+			}
+			if err := s.Write16(data[i:end]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := readBack(f, ReadData16)
+		if err != nil {
+			t.Fatalf("%+v: %v", opt, err)
+		}
+		if !reflect.DeepEqual(data, got) {
+			t.Errorf("%+v: data changed", opt)
+		}
+	}
+}
+
+func TestStreamWriterErrors(t *testing.T) {
+	f := &memFile{}
+	s, err := NewStreamWriter(f, 5, 5, 8, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write16(data16); err == nil {
+		t.Errorf("expected error writing 16 bit data to 8 bit image")
+	}
+	if err := s.Write8(data8[:20]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err == nil {
+		t.Errorf("expected error closing with missing rows")
+	}
+
+	s, err = NewStreamWriter(&memFile{}, 5, 5, 8, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write8(append(data8, 1)); err == nil {
+		t.Errorf("expected error writing past end of image")
+	}
+
+	s, err = NewStreamWriter(&memFile{}, 5, 5, 8, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Write(data8); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Write(data8); err == nil {
+		t.Errorf("expected error writing after close")
+	}
+	if err := s.Close(); err == nil {
+		t.Errorf("expected error closing twice")
+	}
+
+	if _, err := NewStreamWriter(&memFile{}, 5, 5, 8, &Options{Verify: true}); err == nil {
+		t.Errorf("expected error from Verify, which a StreamWriter can't do")
+	}
+}
+
+// StreamWriter must satisfy io.WriteCloser so it can be used with io.Copy
+var _ io.WriteCloser = (*StreamWriter)(nil)
@@ -0,0 +1,132 @@
+package gtiff
+
+import (
+	"fmt"
+	"io"
+)
+
+// StripReader iterates over the strips of an image, or rows of tiles of a tiled image, decoding one at a time
+// so images can be processed with memory bounded by the size of one strip.
+//
+//	s, err := gtiff.NewStripReader(r, header, tags)
+//	for s.Next() {
+//		first, rows := s.Rows()
+//		data, err := s.Data16()
+//		// process rows first to first+rows-1
+//	}
+//	if err := s.Err(); err != nil {
+//	}
+type StripReader struct {
+	r      io.ReaderAt
+	h      Header
+	t      Tags
+	l      layout
+	next   uint32 // first row of the next strip
+	reg    region // rows of the current strip
+	data   []byte // samples of the current strip in the byte order of the file
+	block  []byte // scratch space for decoding
+	data16 []uint16
+	data32 []float32
+	err    error
+}
+
+// NewStripReader returns a StripReader for the image described by h and t. It returns ErrLimit if a strip
+// or row of tiles is larger than DefaultLimits.MaxAlloc.
+func NewStripReader(r io.ReadSeeker, h Header, t Tags) (*StripReader, error) {
+	l, err := readLayout(t)
+	if err != nil {
+		return nil, err
+	}
+	rows := minUint32(l.blockLength, l.length)
+	if n := uint64(l.width) * uint64(rows) * uint64(l.bytesPerSample); n > DefaultLimits.MaxAlloc || uint64(int(n)) != n {
+		return nil, fmt.Errorf("%w: strip of %dx%d with %d bits per sample is larger than %d bytes", ErrLimit, l.width, rows, t.BitsPerSample, DefaultLimits.MaxAlloc)
+	}
+	return &StripReader{r: readerAt(r), h: h, t: t, l: l}, nil
+}
+
+// Next reads and decodes the next strip. It returns false after the last strip or if an error occurs.
+func (s *StripReader) Next() bool {
+	if s.err != nil || s.next >= s.l.length {
+		return false
+	}
+
+	s.reg = region{0, s.next, s.l.width, minUint32(s.l.blockLength, s.l.length-s.next)}
+	n := int(uint64(s.reg.width) * uint64(s.reg.length) * uint64(s.l.bytesPerSample))
+	if cap(s.data) < n {
+		s.data = make([]byte, n)
+	}
+	s.data = s.data[:n]
+	s.block, s.err = decodeRegion(s.r, s.h, s.t, s.l, s.reg, s.data, s.block)
+	if s.err != nil {
+		return false
+	}
+
+	s.next += s.reg.length
+	return true
+}
+
+// Rows returns the first row and number of rows in the current strip.
+func (s *StripReader) Rows() (uint32, uint32) {
+	return s.reg.y, s.reg.length
+}
+
+// Bytes returns the samples of the current strip in the byte order of the file.
+// The slice is only valid until the next call to Next.
+func (s *StripReader) Bytes() []byte {
+	return s.data
+}
+
+// Data8 returns the samples of the current strip of an 8 bit image.
+// The slice is only valid until the next call to Next.
+func (s *StripReader) Data8() ([]uint8, error) {
+	if err := s.check(8); err != nil {
+		return nil, err
+	}
+	return s.data, nil
+}
+
+// Data16 returns the samples of the current strip of a 16 bit image.
+// The slice is only valid until the next call to Next.
+func (s *StripReader) Data16() ([]uint16, error) {
+	if err := s.check(16); err != nil {
+		return nil, err
+	}
+	n := len(s.data) / 2
+	if cap(s.data16) < n {
+		s.data16 = make([]uint16, n)
+	}
+	s.data16 = s.data16[:n]
+	decode16(s.data16, s.data, s.h.ByteOrder)
+	return s.data16, nil
+}
+
+// Data32 returns the samples of the current strip of a 32 bit float image.
+// The slice is only valid until the next call to Next.
+func (s *StripReader) Data32() ([]float32, error) {
+	if err := s.check(32); err != nil {
+		return nil, err
+	}
+	n := len(s.data) / 4
+	if cap(s.data32) < n {
+		s.data32 = make([]float32, n)
+	}
+	s.data32 = s.data32[:n]
+	decode32(s.data32, s.data, s.h.ByteOrder)
+	return s.data32, nil
+}
+
+// Err returns the first error that occurred while reading strips.
+func (s *StripReader) Err() error {
+	return s.err
+}
+
+// check a strip has been read from an image of bitsPerSample bits
+func (s *StripReader) check(bitsPerSample uint16) error {
+	if s.t.BitsPerSample != bitsPerSample {
+		return fmt.Errorf("read: %w, expected %d, got %d", ErrWrongBitsPerSample, bitsPerSample, s.t.BitsPerSample)
+	}
+	if s.reg.length == 0 {
+		return fmt.Errorf("read: no strip, call Next first")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package gtiff
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestStripReader(t *testing.T) {
+	const width, length = 40, 37
+	data := make([]float32, width*length)
+	for i := range data {
+		data[i] = float32(i) / 3
+	}
+
+	for _, opt := range []Options{
+		{RowsPerStrip: 5},
+		{Compression: CompressionDeflate, Predictor: PredictorFloatingPoint, RowsPerStrip: 8},
+		{Compression: CompressionLZW, TileWidth: 16, TileLength: 16},
+	} {
+		f := &memFile{}
+		if err := NewEncoder(f, &opt).Encode32(data, width, length); err != nil {
+			t.Fatal(err)
+		}
+		f.Seek(0, io.SeekStart)
+		tags, header, err := ReadTags(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := NewStripReader(f, header, tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []float32
+		next := uint32(0)
+		for s.Next() {
+			first, rows := s.Rows()
+			if first != next {
+				t.Errorf("%+v: expected strip at row %d, got %d", opt, next, first)
+			}
+			next += rows
+			strip, err := s.Data32()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(strip) != int(rows*width) {
+				t.Errorf("%+v: expected %d samples, got %d", opt, rows*width, len(strip))
+			}
+			got = append(got, strip...)
+		}
+		if err := s.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(data, got) {
+			t.Errorf("%+v: data read by strips does not match data written", opt)
+		}
+		if _, err := s.Data16(); err == nil {
+			t.Errorf("%+v: expected error reading 32 bit strip as 16 bit", opt)
+		}
+	}
+}
+
+func TestStripReaderLimit(t *testing.T) {
+	// a row of sparse tiles of 4 GiB, which would not fit in the uint32 arithmetic used on a strip
+	r := bytes.NewReader(malformedFiles()["huge-sparse-tiles"])
+	tags, header, err := ReadTagsLimits(r, &Limits{MaxPixels: 1 << 40, MaxAlloc: 1 << 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStripReader(r, header, tags); !errors.Is(err, ErrLimit) {
+		t.Errorf("expected ErrLimit, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package gtiff
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// WriteFile8 writes a tiff of uint8 data to the named file with an Encoder using opt, which may be nil.
+// The file is written to a temporary file in the same directory, synced to disk and renamed into place,
+// so the named file is either left as it was or replaced with a complete tiff, never partly written.
+// A file that is replaced keeps its permissions, a new file is created with permissions 0666 less the
+// umask, as with os.Create.
+func WriteFile8(name string, data []uint8, width, length uint32, opt *Options) error {
+	return writeFile(name, func(f *os.File) error {
+		return NewEncoder(f, opt).Encode8(data, width, length)
+	})
+}
+
+// WriteFile16 writes a tiff of uint16 data to the named file like WriteFile8.
+func WriteFile16(name string, data []uint16, width, length uint32, opt *Options) error {
+	return writeFile(name, func(f *os.File) error {
+		return NewEncoder(f, opt).Encode16(data, width, length)
+	})
+}
+
+// WriteFile32 writes a tiff of float32 data to the named file like WriteFile8.
+func WriteFile32(name string, data []float32, width, length uint32, opt *Options) error {
+	return writeFile(name, func(f *os.File) error {
+		return NewEncoder(f, opt).Encode32(data, width, length)
+	})
+}
+
+// write the named file with write through a temporary file renamed into place once complete
+func writeFile(name string, write func(f *os.File) error) error {
+	fi, statErr := os.Stat(name)
+
+	dir, base := filepath.Split(name)
+	if dir == "" {
+		dir = "."
+	}
+	f, err := createTemp(dir, base)
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	// remove the temporary file unless it was renamed
+	done := false
+	defer func() {
+		if !done {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	if err := write(f); err != nil {
+		return err
+	}
+	if statErr == nil {
+		if err := f.Chmod(fi.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		return err
+	}
+	done = true
+
+	// sync the directory so the rename survives a crash, not possible on every system
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// create a new temporary file in dir named after base, with permissions 0666 less the umask
+// like os.Create, rather than the 0600 of ioutil.TempFile
+func createTemp(dir, base string) (*os.File, error) {
+	for try := 0; ; try++ {
+		name := filepath.Join(dir, "."+base+".tmp"+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && try < 10000 {
+			continue
+		}
+		return f, err
+	}
+}
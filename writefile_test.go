@@ -0,0 +1,82 @@
+package gtiff
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gtiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "out.tif")
+
+	data := make([]uint16, 20*10)
+	for i := range data {
+		data[i] = uint16(i)
+	}
+	if err := WriteFile16(name, data, 20, 10, &Options{Compression: CompressionLZW}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new file has the permissions of one made by os.Create
+	created, err := os.Create(filepath.Join(dir, "created"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := created.Stat()
+	created.Close()
+	os.Remove(created.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(name); err != nil || fi.Mode().Perm() != want.Mode().Perm() {
+		t.Errorf("expected permissions %v, got %v %v", want.Mode().Perm(), fi.Mode(), err)
+	}
+
+	if err := os.Chmod(name, 0600); err != nil {
+		t.Fatal(err)
+	}
+	data[0] = 7
+	if err := WriteFile16(name, data, 20, 10, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed write leaves the file as it was and no temporary files behind
+	if err := WriteFile16(name, data[:10], 20, 10, nil); !errors.Is(err, ErrDataSize) {
+		t.Errorf("expected ErrDataSize, got %v", err)
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 {
+		t.Errorf("expected only %s, got %v", name, names)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if fi, err := f.Stat(); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions kept, got %v %v", fi.Mode(), err)
+	}
+	tags, header, err := ReadTags(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadData16(f, header, tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data, got) {
+		t.Error("data changed")
+	}
+}
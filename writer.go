@@ -1,8 +1,13 @@
 package gtiff
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
+	"math"
 )
 
 type header struct {
@@ -11,311 +16,580 @@ type header struct {
 	ifdOffset      uint32
 }
 
-type dir16 struct {
-	tag   uint16
-	dtype uint16
-	count uint32
-	value uint16
-	pad   uint16
+type bigHeader struct {
+	byteOrder      uint16
+	tiffIdentifier uint16
+	offsetSize     uint16
+	reserved       uint16
+	ifdOffset      uint64
 }
 
-type dir32 struct {
-	tag   uint16
-	dtype uint16
-	count uint32
-	value uint32
+// DefaultStripSize is the strip size recommended by the tiff 6.0 spec.
+const DefaultStripSize = 8 * 1024
+
+// size of the buffers small writes are gathered in before being written
+const writeBufferSize = 64 * 1024
+
+// Options configure how an Encoder writes an image.
+// The zero value writes an uncompressed little endian image in strips of about DefaultStripSize bytes.
+type Options struct {
+	// ByteOrder of the file, defaults to binary.LittleEndian.
+	ByteOrder binary.ByteOrder
+
+	// Compression is one of CompressionNone, CompressionLZW, CompressionDeflate or CompressionPackBits.
+	Compression uint16
+
+	// Predictor is applied before compression, one of PredictorNone, PredictorHorizontal or
+	// PredictorFloatingPoint (float32 data only).
+	Predictor uint16
+
+	// RowsPerStrip is the number of rows in each strip.
+	RowsPerStrip uint32
+
+	// StripSize is the target uncompressed size of each strip in bytes, used when RowsPerStrip is 0.
+	// Strips hold at least 1 row. Defaults to DefaultStripSize, a negative value writes the whole
+	// image in one strip.
+	StripSize int
+
+	// TileWidth and TileLength write the image in tiles instead of strips when set.
+	// Both must be multiples of 16.
+	TileWidth  uint32
+	TileLength uint32
+
+	// WhiteIsZero writes PhotometricInterpretation 0 instead of 1 (BlackIsZero), whatever Metadata holds.
+	WhiteIsZero bool
+
+	// Metadata holds the resolution, ascii and extra tags to write, for instance tags read from another file.
+	// Tags describing the layout and encoding of the original pixel data are ignored, except that the
+	// PhotometricInterpretation of tags read from a file, which have ImageWidth set, is kept and a
+	// SampleFormat of signed integers is kept for data of the same bits per sample.
+	Metadata Tags
+
+	// ExtraTags are written along with Metadata.Extra and take precedence over it.
+	// Tags set by any other option are not replaced.
+	ExtraTags []Field
+
+	// Software, if set, is stamped in the Software tag along with the time of writing in DateTime.
+	Software string
+
+	// BigTIFF writes 64 bit offsets, required for files larger than 4 GiB.
+	BigTIFF bool
+
+	// Concurrency is the number of goroutines compressing strips or tiles in parallel, such as
+	// runtime.NumCPU(). They are still written in order. 0 or 1 compresses them one at a time.
+	// A StreamWriter buffers Concurrency strips or rows of tiles at a time.
+	Concurrency int
+
+	// Verify reads the file back after writing it and returns ErrVerify if it does not hold the
+	// data written. The writer must also be an io.ReadSeeker, such as an *os.File, and the file
+	// must start at its beginning. NewStreamWriter returns an error if it is set.
+	Verify bool
 }
 
-// WriteTiff8 writes a tiff from a slice of uint8 data.
-func WriteTiff8(w io.WriteSeeker, byteOrder binary.ByteOrder, data []uint8, width uint32, length uint32) error {
-	// steps:
-	// 1) write all image data starting at offset 8, seek to next word boundry and save offset
-	// 2) write 1 ifd of 11 directory entries
-	// 3) write all 11 directory entries, 1 for each required tag
-	// 4) point stripOffset to 8
-	// 5) write 4 bytes of 0 to indicate last ifd
-	// 6) write header at offset 0 with ifdOffset from saved after image data
+// Encoder writes images as tiffs configured by its Options.
+type Encoder struct {
+	w       io.Writer
+	opt     Options
+	forward bool // only write forward, never seek
+}
 
-	// 1)
-	if _, err := w.Seek(8, 0); err != nil {
-		return err
-	}
-	if err := binary.Write(w, byteOrder, data); err != nil {
-		return err
-	}
-	afterData, _ := w.Seek(0, io.SeekCurrent)
-	// seek to next work boundry
-	afterData = afterData/8*8 + 8
-	w.Seek(afterData, 0)
+// NewEncoder returns an Encoder that writes to w, opt may be nil to use the defaults.
+// Strips or tiles are written as they are compressed followed by the ifd, then the header
+// is updated with the offset of the ifd.
+func NewEncoder(w io.WriteSeeker, opt *Options) *Encoder {
+	return newEncoder(w, opt, false)
+}
 
-	// 2)
-	if err := binary.Write(w, byteOrder, uint16(11)); err != nil {
-		return err
-	}
-	// 3-4)
-	// ImageWidth
-	if err := binary.Write(w, byteOrder, newDir32(256, width)); err != nil {
-		return err
-	}
-	// ImageLength
-	if err := binary.Write(w, byteOrder, newDir32(257, length)); err != nil {
-		return err
-	}
-	// BitsPerSample
-	if err := binary.Write(w, byteOrder, newDir16(258, 8)); err != nil {
-		return err
-	}
-	// Compression
-	if err := binary.Write(w, byteOrder, newDir16(259, 1)); err != nil {
-		return err
-	}
-	// PhotometricInterpretation
-	if err := binary.Write(w, byteOrder, newDir16(262, 1)); err != nil {
-		return err
-	}
-	// StripOffsets
-	if err := binary.Write(w, byteOrder, newDir32(273, 8)); err != nil {
-		return err
+// NewForwardEncoder returns an Encoder that writes to w without seeking, for writing to pipes,
+// network connections or compressed streams. opt may be nil to use the defaults.
+// The ifd is written right after the header, so all strips or tiles are compressed in memory
+// before anything is written. Uncompressed images are written without buffering.
+func NewForwardEncoder(w io.Writer, opt *Options) *Encoder {
+	return newEncoder(w, opt, true)
+}
+
+func newEncoder(w io.Writer, opt *Options, forward bool) *Encoder {
+	e := &Encoder{w: w, forward: forward}
+	if opt != nil {
+		e.opt = *opt
 	}
-	// RowsPerStrip
-	if err := binary.Write(w, byteOrder, newDir32(278, length)); err != nil {
-		return err
+	if e.opt.ByteOrder == nil {
+		e.opt.ByteOrder = binary.LittleEndian
 	}
-	// StripByteCounts
-	if err := binary.Write(w, byteOrder, newDir32(279, width*length)); err != nil {
+	return e
+}
+
+// Encode8 writes a tiff from a slice of uint8 data.
+func (e *Encoder) Encode8(data []uint8, width uint32, length uint32) error {
+	return e.encode(data, width, length, 8, 1)
+}
+
+// Encode16 writes a tiff from a slice of uint16 data.
+func (e *Encoder) Encode16(data []uint16, width uint32, length uint32) error {
+	return e.encode(data, width, length, 16, 1)
+}
+
+// Encode32 writes a tiff from a slice of float32 data.
+func (e *Encoder) Encode32(data []float32, width uint32, length uint32) error {
+	return e.encode(data, width, length, 32, 3)
+}
+
+// WriteTiff8 writes a tiff from a slice of uint8 data.
+func WriteTiff8(w io.WriteSeeker, byteOrder binary.ByteOrder, data []uint8, width uint32, length uint32) error {
+	return NewEncoder(w, &Options{ByteOrder: byteOrder}).Encode8(data, width, length)
+}
+
+// WriteTiff16 writes a tiff from a slice of uint16 data.
+func WriteTiff16(w io.WriteSeeker, byteOrder binary.ByteOrder, data []uint16, width uint32, length uint32) error {
+	return NewEncoder(w, &Options{ByteOrder: byteOrder}).Encode16(data, width, length)
+}
+
+// WriteTiff32 write a tiff from a slice of float32 data.
+func WriteTiff32(w io.WriteSeeker, byteOrder binary.ByteOrder, data []float32, width uint32, length uint32) error {
+	return NewEncoder(w, &Options{ByteOrder: byteOrder}).Encode32(data, width, length)
+}
+
+// WriteTiffTags8 writes a tiff from a slice of uint8 data.
+// The image dimensions, resolution, ascii tags and extra tags are taken from t,
+// so tags read from one file can be written to a new one along with new data.
+func WriteTiffTags8(w io.WriteSeeker, byteOrder binary.ByteOrder, data []uint8, t Tags) error {
+	return NewEncoder(w, &Options{ByteOrder: byteOrder, Metadata: t}).Encode8(data, t.ImageWidth, t.ImageLength)
+}
+
+// WriteTiffTags16 writes a tiff from a slice of uint16 data.
+// The image dimensions, resolution, ascii tags and extra tags are taken from t,
+// so tags read from one file can be written to a new one along with new data.
+func WriteTiffTags16(w io.WriteSeeker, byteOrder binary.ByteOrder, data []uint16, t Tags) error {
+	return NewEncoder(w, &Options{ByteOrder: byteOrder, Metadata: t}).Encode16(data, t.ImageWidth, t.ImageLength)
+}
+
+// WriteTiffTags32 writes a tiff from a slice of float32 data.
+// The image dimensions, resolution, ascii tags and extra tags are taken from t,
+// so tags read from one file can be written to a new one along with new data.
+func WriteTiffTags32(w io.WriteSeeker, byteOrder binary.ByteOrder, data []float32, t Tags) error {
+	return NewEncoder(w, &Options{ByteOrder: byteOrder, Metadata: t}).Encode32(data, t.ImageWidth, t.ImageLength)
+}
+
+// write data, a []uint8, []uint16 or []float32, as a tiff
+func (e *Encoder) encode(data interface{}, width, length uint32, bitsPerSample, sampleFormat uint16) error {
+	n := uint64(width) * uint64(length)
+	if err := checkDataSize(data, n); err != nil {
 		return err
 	}
-	// XResolution
-	if err := binary.Write(w, byteOrder, newDir32(282, 0)); err != nil {
-		return err
+	var r io.ReaderAt
+	if e.opt.Verify {
+		rs, ok := e.w.(io.ReadSeeker)
+		if !ok {
+			return fmt.Errorf("write: Verify needs a writer that can be read back, got %T", e.w)
+		}
+		r = readerAt(rs)
 	}
-	// YResolution
-	if err := binary.Write(w, byteOrder, newDir32(283, 0)); err != nil {
+
+	if err := e.write(data, n, width, length, bitsPerSample, sampleFormat); err != nil {
 		return err
 	}
-	// ResolutionUnit
-	if err := binary.Write(w, byteOrder, newDir16(296, 0)); err != nil {
-		return err
+	if r != nil {
+		return verify(r, data)
 	}
+	return nil
+}
 
-	// 5)
-	if err := binary.Write(w, byteOrder, []byte{0, 0, 0, 0}); err != nil {
-		return err
+// write data holding n samples as a tiff, seeking back to the header if the writer allows it
+func (e *Encoder) write(data interface{}, n uint64, width, length uint32, bitsPerSample, sampleFormat uint16) error {
+	ws, ok := e.w.(io.WriteSeeker)
+	if e.forward || !ok {
+		raw, err := sampleBytes(data, n, e.opt.ByteOrder)
+		if err != nil {
+			return err
+		}
+		return e.encodeForward(raw, width, length, bitsPerSample, sampleFormat)
 	}
 
-	// 6)
-	var bo uint16 = 0X4949 // default to little endian
-	if byteOrder == binary.BigEndian {
-		bo = 0X4D4D // big endian code
-	}
-	// create header
-	h := header{bo, 42, uint32(afterData)}
-	if _, err := w.Seek(0, 0); err != nil {
+	s, err := newStreamWriter(ws, width, length, bitsPerSample, sampleFormat, e.opt)
+	if err != nil {
 		return err
 	}
-	if err := binary.Write(w, byteOrder, h); err != nil {
+	if err := s.writeData(data, n, bitsPerSample); err != nil {
 		return err
 	}
+	return s.Close()
+}
 
+// read back the first image of the file written to r and check it holds data
+func verify(r io.ReaderAt, data interface{}) error {
+	reader, err := NewReader(r)
+	if err != nil {
+		return fmt.Errorf("write: %w: %v", ErrVerify, err)
+	}
+	var want, got []byte
+	switch d := data.(type) {
+	case []uint8:
+		var g []uint8
+		g, err = reader.Data8(0)
+		want, got = d, g
+	case []uint16:
+		var g []uint16
+		g, err = reader.Data16(0)
+		want, got = uint16Bytes(d), uint16Bytes(g)
+	case []float32:
+		var g []float32
+		g, err = reader.Data32(0)
+		want, got = float32Bytes(d), float32Bytes(g)
+	}
+	if err != nil {
+		return fmt.Errorf("write: %w: %v", ErrVerify, err)
+	}
+	if !bytes.Equal(want, got) {
+		return fmt.Errorf("write: %w", ErrVerify)
+	}
 	return nil
 }
 
-// WriteTiff16 writes a tiff from a slice of uint16 data.
-func WriteTiff16(w io.WriteSeeker, byteOrder binary.ByteOrder, data []uint16, width uint32, length uint32) error {
+// write raw image bytes as a tiff without seeking
+func (e *Encoder) encodeForward(raw []byte, width, length uint32, bitsPerSample, sampleFormat uint16) error {
 	// steps:
-	// 1) write all image data starting at offset 8, seek to next word boundry and save offset
-	// 2) write 1 ifd of 11 directory entries
-	// 3) write all 11 directory entries, 1 for each required tag
-	// 4) point stripOffset to 8
-	// 5) write 4 bytes of 0 to indicate last ifd
-	// 6) write header at offset 0 with ifdOffset from saved after image data
+	// 1) compress all strips or tiles to find their sizes, uncompressed sizes are known without compressing
+	// 2) build 1 ifd with placeholder offsets to find its size
+	// 3) set offsets of strips or tiles following the header and ifd
+	// 4) write header, ifd and then strips or tiles
 
 	// 1)
-	if _, err := w.Seek(8, 0); err != nil {
-		return err
+	opt := e.opt
+	t, l, err := opt.structure(width, length, bitsPerSample, sampleFormat)
+	if err != nil {
+		return err
+	}
+	uncompressed := (opt.Compression == 0 || opt.Compression == CompressionNone) && !l.tiled
+	blocks := make([][]byte, l.blocks())
+	offsets := make([]uint64, l.blocks())
+	counts := make([]uint64, l.blocks())
+	if uncompressed {
+		for i := range counts {
+			counts[i] = uint64(l.blockBytes(i))
+		}
+	} else {
+		all := make([]int, l.blocks())
+		for i := range all {
+			all[i] = i
+		}
+		fill := func(b []byte, i int) { l.copyFromImage(b, raw, 0, i) }
+		keep := func(i int, c []byte) error {
+			blocks[i], counts[i] = c, uint64(len(c))
+			return nil
+		}
+		if err := opt.encodeBlocks(l, all, nil, fill, keep); err != nil {
+			return err
+		}
 	}
-	if err := binary.Write(w, byteOrder, data); err != nil {
-		return err
-	}
-	afterData, _ := w.Seek(0, io.SeekCurrent)
-	// seek to next work boundry
-	afterData = afterData/8*8 + 8
-	w.Seek(afterData, 0)
 
 	// 2)
-	if err := binary.Write(w, byteOrder, uint16(11)); err != nil {
+	d := opt.ifd(t, offsets, counts)
+	if err := opt.metadata(&d); err != nil {
 		return err
 	}
-	// 3-4)
-	// ImageWidth
-	if err := binary.Write(w, byteOrder, newDir32(256, width)); err != nil {
-		return err
-	}
-	// ImageLength
-	if err := binary.Write(w, byteOrder, newDir32(257, length)); err != nil {
-		return err
+
+	// 3)
+	ifdOffset := opt.headerBytes()
+	pos := ifdOffset + d.size()
+	for i := range offsets {
+		offsets[i] = pos
+		pos += counts[i]
 	}
-	// BitsPerSample
-	if err := binary.Write(w, byteOrder, newDir16(258, 16)); err != nil {
-		return err
+	if !opt.BigTIFF && pos > math.MaxUint32 {
+		return errors.New("write: file larger than 4 GiB requires BigTIFF")
 	}
-	// Compression
-	if err := binary.Write(w, byteOrder, newDir16(259, 1)); err != nil {
+	d = opt.ifd(t, offsets, counts)
+	if err := opt.metadata(&d); err != nil {
 		return err
 	}
-	// PhotometricInterpretation
-	if err := binary.Write(w, byteOrder, newDir16(262, 1)); err != nil {
+
+	// 4)
+	w := bufio.NewWriterSize(e.w, writeBufferSize)
+	if err := binary.Write(w, opt.ByteOrder, opt.header(ifdOffset)); err != nil {
 		return err
 	}
-	// StripOffsets
-	if err := binary.Write(w, byteOrder, newDir32(273, 8)); err != nil {
+	if _, err := w.Write(d.encode(ifdOffset)); err != nil {
 		return err
 	}
-	// RowsPerStrip
-	if err := binary.Write(w, byteOrder, newDir32(278, length)); err != nil {
-		return err
+	for i, b := range blocks {
+		if uncompressed {
+			// strips are consecutive rows of the image
+			b = raw[offsets[i]-offsets[0] : offsets[i]-offsets[0]+counts[i]]
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
 	}
-	// StripByteCounts
-	if err := binary.Write(w, byteOrder, newDir32(279, width*length)); err != nil {
-		return err
-	}
-	// XResolution
-	if err := binary.Write(w, byteOrder, newDir32(282, 0)); err != nil {
-		return err
+
+	return w.Flush()
+}
+
+// structural tags and layout of strips or tiles for an image written with opt
+func (opt Options) structure(width, length uint32, bitsPerSample, sampleFormat uint16) (Tags, layout, error) {
+	t := Tags{
+		ImageWidth:    width,
+		ImageLength:   length,
+		BitsPerSample: bitsPerSample,
+		RowsPerStrip:  opt.rowsPerStrip(width, bitsPerSample),
+		TileWidth:     opt.TileWidth,
+		TileLength:    opt.TileLength,
+		SampleFormat:  sampleFormat,
+	}
+	l, err := newLayout(t)
+	if err != nil {
+		return t, l, err
+	}
+	return t, l, opt.check(t)
+}
+
+// size of the header in bytes
+func (opt Options) headerBytes() uint64 {
+	if opt.BigTIFF {
+		return 16
 	}
-	// YResolution
-	if err := binary.Write(w, byteOrder, newDir32(283, 0)); err != nil {
-		return err
+	return 8
+}
+
+// apply predictor and compression to strip or tile b, b may be modified
+func (opt Options) encodeBlock(l layout, b []byte) ([]byte, error) {
+	if err := applyPredictor(opt.Predictor, b, int(l.blockWidth), int(l.bytesPerSample), opt.ByteOrder); err != nil {
+		return nil, err
 	}
-	// ResolutionUnit
-	if err := binary.Write(w, byteOrder, newDir16(296, 0)); err != nil {
-		return err
+	return compress(opt.Compression, b, int(l.blockWidth*l.bytesPerSample))
+}
+
+// compress strips or tiles and pass them to write in order of blocks, fill copies the raw samples of
+// block i into b. Blocks are compressed by up to opt.Concurrency goroutines. When compressing one
+// at a time, block is reused for the raw samples of each block, nil allocates one for each block
+// so compressed blocks can be kept.
+func (opt Options) encodeBlocks(l layout, blocks []int, block []byte, fill func(b []byte, i int), write func(i int, c []byte) error) error {
+	concurrency := opt.Concurrency
+	if concurrency > len(blocks) {
+		concurrency = len(blocks)
+	}
+	if concurrency <= 1 || opt.Compression == 0 || opt.Compression == CompressionNone {
+		// uncompressed blocks are written from the raw samples, so workers would share them
+		for _, i := range blocks {
+			b := block
+			if b == nil {
+				b = make([]byte, l.blockBytes(i))
+			}
+			b = b[:l.blockBytes(i)]
+			fill(b, i)
+			c, err := opt.encodeBlock(l, b)
+			if err != nil {
+				return err
+			}
+			if err := write(i, c); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
-	// 5)
-	if err := binary.Write(w, byteOrder, []byte{0, 0, 0, 0}); err != nil {
-		return err
+	// workers compress blocks[k] into out[k], which are then written in order
+	out := make([][]byte, len(blocks))
+	next := make(chan int)
+	errs := make(chan error, concurrency)
+	for w := 0; w < concurrency; w++ {
+		go func() {
+			b := make([]byte, l.blockBytes(0))
+			var err error
+			for k := range next {
+				if err != nil {
+					continue
+				}
+				i := blocks[k]
+				fill(b[:l.blockBytes(i)], i)
+				out[k], err = opt.encodeBlock(l, b[:l.blockBytes(i)])
+			}
+			errs <- err
+		}()
 	}
 
-	// 6)
-	var bo uint16 = 0X4949 // default to little endian
-	if byteOrder == binary.BigEndian {
-		bo = 0X4D4D // big endian code
+	for k := range blocks {
+		next <- k
 	}
-	// create header
-	h := header{bo, 42, uint32(afterData)}
-	if _, err := w.Seek(0, 0); err != nil {
-		return err
+	close(next)
+	var failed error
+	for w := 0; w < concurrency; w++ {
+		if err := <-errs; err != nil && failed == nil {
+			failed = err
+		}
 	}
-	if err := binary.Write(w, byteOrder, h); err != nil {
-		return err
+	if failed != nil {
+		return failed
 	}
 
+	for k, i := range blocks {
+		if err := write(i, out[k]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// WriteTiff32 write a tiff from a slice of float32 data.
-func WriteTiff32(w io.WriteSeeker, byteOrder binary.ByteOrder, data []float32, width uint32, length uint32) error {
-	// steps:
-	// 1) write all image data starting at offset 8, seek to next word boundry and save offset
-	// 2) write 1 ifd of 12 directory entries
-	// 3) write all 12 directory entries, 1 for each required tag + sample format
-	// 4) point stripOffset to 8
-	// 5) write 4 bytes of 0 to indicate last ifd
-	// 6) write header at offset 0 with ifdOffset from saved after image data
-
-	// 1)
-	if _, err := w.Seek(8, 0); err != nil {
-		return err
+// rows in each strip of an image width samples wide
+func (opt Options) rowsPerStrip(width uint32, bitsPerSample uint16) uint32 {
+	if opt.RowsPerStrip != 0 {
+		return opt.RowsPerStrip
 	}
-	if err := binary.Write(w, byteOrder, data); err != nil {
-		return err
-	}
-	afterData, _ := w.Seek(0, io.SeekCurrent)
-	// seek to next work boundry
-	afterData = afterData/8*8 + 8
-	w.Seek(afterData, 0)
 
-	// 2)
-	if err := binary.Write(w, byteOrder, uint16(12)); err != nil {
-		return err
-	}
-	// 3-4)
-	// ImageWidth
-	if err := binary.Write(w, byteOrder, newDir32(256, width)); err != nil {
-		return err
+	size := opt.StripSize
+	switch {
+	case size < 0:
+		return 0 // whole image
+	case size == 0:
+		size = DefaultStripSize
 	}
-	// ImageLength
-	if err := binary.Write(w, byteOrder, newDir32(257, length)); err != nil {
-		return err
+	rowBytes := uint64(width) * uint64(bitsPerSample/8)
+	if rowBytes == 0 {
+		return 0
 	}
-	// BitsPerSample
-	if err := binary.Write(w, byteOrder, newDir16(258, 32)); err != nil {
-		return err
+	rows := uint64(size) / rowBytes
+	if rows == 0 {
+		return 1
 	}
-	// Compression
-	if err := binary.Write(w, byteOrder, newDir16(259, 1)); err != nil {
-		return err
+	if rows > math.MaxUint32 {
+		return 0
 	}
-	// PhotometricInterpretation
-	if err := binary.Write(w, byteOrder, newDir16(262, 1)); err != nil {
-		return err
-	}
-	// StripOffsets
-	if err := binary.Write(w, byteOrder, newDir32(273, 8)); err != nil {
-		return err
-	}
-	// RowsPerStrip
-	if err := binary.Write(w, byteOrder, newDir32(278, length)); err != nil {
-		return err
+	return uint32(rows)
+}
+
+// check options are valid for writing an image described by t
+func (opt Options) check(t Tags) error {
+	switch opt.Compression {
+	case 0, CompressionNone, CompressionLZW, CompressionDeflate, CompressionPackBits:
+	default:
+		return fmt.Errorf("write: %w, got %d", ErrUnsupportedCompression, opt.Compression)
 	}
-	// StripByteCounts
-	if err := binary.Write(w, byteOrder, newDir32(279, width*length)); err != nil {
-		return err
+
+	switch opt.Predictor {
+	case 0, PredictorNone:
+	case PredictorHorizontal, PredictorFloatingPoint:
+		if opt.Compression == 0 || opt.Compression == CompressionNone {
+			return errors.New("write: predictor requires compression")
+		}
+		if opt.Predictor == PredictorFloatingPoint && t.SampleFormat != 3 {
+			return errors.New("write: floating point predictor requires float data")
+		}
+	default:
+		return fmt.Errorf("write: %w, got %d", ErrUnsupportedPredictor, opt.Predictor)
 	}
-	// XResolution
-	if err := binary.Write(w, byteOrder, newDir32(282, 0)); err != nil {
-		return err
+
+	if opt.TileWidth%16 != 0 || opt.TileLength%16 != 0 {
+		return fmt.Errorf("write: tile size must be a multiple of 16, got %dx%d", opt.TileWidth, opt.TileLength)
 	}
-	// YResolution
-	if err := binary.Write(w, byteOrder, newDir32(283, 0)); err != nil {
-		return err
+
+	return nil
+}
+
+// ifd with 1 directory entry for each structural tag of the image described by t
+func (opt Options) ifd(t Tags, offsets, counts []uint64) ifdBuilder {
+	d := ifdBuilder{byteOrder: opt.ByteOrder, bigTIFF: opt.BigTIFF}
+
+	compression := opt.Compression
+	if compression == 0 {
+		compression = CompressionNone
 	}
-	// ResolutionUnit
-	if err := binary.Write(w, byteOrder, newDir16(296, 0)); err != nil {
-		return err
+	// PhotometricInterpretation and signed samples are kept from tags read from another file
+	m := opt.Metadata
+	var photometric uint16 = 1
+	if opt.WhiteIsZero || (m.ImageWidth != 0 && m.PhotometricInterpretation == 0) {
+		photometric = 0
 	}
-	// SampleFormat (3 is IEEE floating point, default without tag is 1 uint)
-	if err := binary.Write(w, byteOrder, newDir16(339, 3)); err != nil {
-		return err
+	sampleFormat := t.SampleFormat
+	if sampleFormat == 1 && m.SampleFormat == 2 && m.BitsPerSample == t.BitsPerSample {
+		sampleFormat = 2
 	}
 
-	// 5)
-	if err := binary.Write(w, byteOrder, []byte{0, 0, 0, 0}); err != nil {
-		return err
+	d.long(256, t.ImageWidth)     // ImageWidth
+	d.long(257, t.ImageLength)    // ImageLength
+	d.short(258, t.BitsPerSample) // BitsPerSample
+	d.short(259, compression)     // Compression
+	d.short(262, photometric)     // PhotometricInterpretation
+	if t.tiled() {
+		d.long(322, t.TileWidth)   // TileWidth
+		d.long(323, t.TileLength)  // TileLength
+		d.offsets(324, offsets...) // TileOffsets
+		d.offsets(325, counts...)  // TileByteCounts
+	} else {
+		rowsPerStrip := t.RowsPerStrip
+		if rowsPerStrip == 0 || rowsPerStrip > t.ImageLength {
+			rowsPerStrip = t.ImageLength
+		}
+		d.offsets(273, offsets...) // StripOffsets
+		d.long(278, rowsPerStrip)  // RowsPerStrip
+		d.offsets(279, counts...)  // StripByteCounts
+	}
+	if opt.Predictor != 0 && opt.Predictor != PredictorNone {
+		d.short(317, opt.Predictor) // Predictor
+	}
+	if sampleFormat != 1 {
+		// SampleFormat (2 is signed int, 3 is IEEE floating point, default without tag is 1 uint)
+		d.short(339, sampleFormat)
 	}
 
-	// 6)
-	var bo uint16 = 0X4949 // default to little endian
-	if byteOrder == binary.BigEndian {
-		bo = 0X4D4D // big endian code
-	}
-	// create header
-	h := header{bo, 42, uint32(afterData)}
-	if _, err := w.Seek(0, 0); err != nil {
-		return err
+	return d
+}
+
+// add the resolution, ascii and extra tags to d
+func (opt Options) metadata(d *ifdBuilder) error {
+	t := opt.Metadata
+	if opt.Software != "" {
+		t.Stamp(opt.Software)
 	}
-	if err := binary.Write(w, byteOrder, h); err != nil {
-		return err
+
+	xRes, yRes, unit := t.resolution()
+	d.rational(282, xRes[0], xRes[1]) // XResolution
+	d.rational(283, yRes[0], yRes[1]) // YResolution
+	d.short(296, unit)                // ResolutionUnit
+	d.ascii(269, t.DocumentName)      // DocumentName
+	d.ascii(270, t.ImageDescription)  // ImageDescription
+	d.ascii(271, t.Make)              // Make
+	d.ascii(272, t.Model)             // Model
+	d.ascii(285, t.PageName)          // PageName
+	d.ascii(305, t.Software)          // Software
+	d.ascii(306, t.DateTime)          // DateTime
+	d.ascii(315, t.Artist)            // Artist
+	d.ascii(316, t.HostComputer)      // HostComputer
+	d.ascii(33432, t.Copyright)       // Copyright
+
+	// all other tags except those describing the original pixel data
+	extra := append(append([]Field{}, opt.ExtraTags...), t.Extra...)
+	for _, f := range extra {
+		if !copyableTag(f.Tag) || d.has(f.Tag) {
+			continue
+		}
+		if err := d.field(f); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func newDir16(tag uint16, value uint16) dir16 {
-	return dir16{tag, 3, 1, value, 0}
+// header pointing to the ifd at ifdOffset
+func (opt Options) header(ifdOffset uint64) interface{} {
+	var bo uint16 = 0x4949 // default to little endian
+	if opt.ByteOrder == binary.BigEndian {
+		bo = 0x4D4D // big endian code
+	}
+	if opt.BigTIFF {
+		return bigHeader{bo, 43, 8, 0, ifdOffset}
+	}
+	return header{bo, 42, uint32(ifdOffset)}
 }
 
-func newDir32(tag uint16, value uint32) dir32 {
-	return dir32{tag, 4, 1, value}
+// reports if an extra tag can be copied to a new file, tags describing the layout and
+// encoding of pixel data are written from the new data and tags pointing to other
+// structures in the original file (sub ifds, exif, gps) can't be copied
+func copyableTag(tag uint16) bool {
+	switch tag {
+	case 256, 257, 258, 259, 262, 273, 277, 278, 279, 284, 317, 322, 323, 324, 325, 338, 339, 347:
+		return false
+	case 330, 34665, 34853, 40965:
+		return false
+	}
+	return true
 }
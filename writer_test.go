@@ -1,8 +1,12 @@
 package gtiff
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
+	"io"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -65,3 +69,326 @@ var data32 = []float32{
 	0.0, 0.0, 1.0, 0.0, 0.0,
 	0.0, 1.0, 0.0, 1.0, 0.0,
 	1.0, 0.0, 0.0, 0.0, 1.0}
+
+func TestWriteStrips(t *testing.T) {
+	const width, length = 100, 1000
+	data := make([]uint16, width*length)
+	for i := range data {
+		data[i] = uint16(i)
+	}
+
+	tests := []struct {
+		opt          Options
+		rowsPerStrip uint32
+		strips       int
+	}{
+		{Options{}, 40, 25}, // 8 KB default
+		{Options{StripSize: 64 * 1024}, 327, 4},
+		{Options{StripSize: 1}, 1, 1000},
+		{Options{StripSize: -1}, 1000, 1},
+		{Options{RowsPerStrip: 3, StripSize: 64 * 1024}, 3, 334},
+	}
+
+	for _, test := range tests {
+		f := &memFile{}
+		if err := NewEncoder(f, &test.opt).Encode16(data, width, length); err != nil {
+			t.Fatal(err)
+		}
+
+		f.Seek(0, io.SeekStart)
+		tags, header, err := ReadTags(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tags.RowsPerStrip != test.rowsPerStrip {
+			t.Errorf("%+v: expected %d rows per strip, got %d", test.opt, test.rowsPerStrip, tags.RowsPerStrip)
+		}
+		if len(tags.StripOffsets) != test.strips || len(tags.StripByteCounts) != test.strips {
+			t.Errorf("%+v: expected %d strips, got %d offsets and %d byte counts", test.opt, test.strips, len(tags.StripOffsets), len(tags.StripByteCounts))
+		}
+
+		got, err := ReadData16(f, header, tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(data, got) {
+			t.Errorf("%+v: data changed", test.opt)
+		}
+	}
+}
+
+func TestForwardEncoder(t *testing.T) {
+	const width, length = 50, 41
+	data := make([]float32, width*length)
+	for i := range data {
+		data[i] = float32(i) / 7
+	}
+
+	for _, opt := range []Options{
+		{},
+		{ByteOrder: binary.BigEndian, RowsPerStrip: 5, BigTIFF: true},
+		{Compression: CompressionDeflate, Predictor: PredictorFloatingPoint, Software: "gtiff test"},
+		{Compression: CompressionLZW, TileWidth: 16, TileLength: 16},
+		{TileWidth: 32, TileLength: 32},
+	} {
+		var buf bytes.Buffer
+		// hide any methods other than Write
+		w := struct{ io.Writer }{&buf}
+		if err := NewForwardEncoder(w, &opt).Encode32(data, width, length); err != nil {
+			t.Fatal(err)
+		}
+
+		r := bytes.NewReader(buf.Bytes())
+		tags, header, err := ReadTags(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.IFDOffset != 8 && header.IFDOffset != 16 {
+			t.Errorf("%+v: expected ifd after header, got offset %d", opt, header.IFDOffset)
+		}
+		got, err := ReadData32(r, header, tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(data, got) {
+			t.Errorf("%+v: data changed", opt)
+		}
+	}
+}
+
+func TestEncodeConcurrency(t *testing.T) {
+	const width, length = 123, 301
+	data := make([]uint16, width*length)
+	for i := range data {
+		data[i] = uint16(i * 31)
+	}
+
+	for _, opt := range []Options{
+		{Compression: CompressionLZW, Predictor: PredictorHorizontal, RowsPerStrip: 7},
+		{Compression: CompressionDeflate, TileWidth: 32, TileLength: 16},
+		{Compression: CompressionPackBits, RowsPerStrip: 1},
+		{RowsPerStrip: 5},
+	} {
+		// parallel compression writes the same file as compressing one block at a time
+		expected, forward := &memFile{}, bytes.Buffer{}
+		if err := NewEncoder(expected, &opt).Encode16(data, width, length); err != nil {
+			t.Fatal(err)
+		}
+		if err := NewForwardEncoder(&forward, &opt).Encode16(data, width, length); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, concurrency := range []int{2, 8, 1000} {
+			opt := opt
+			opt.Concurrency = concurrency
+
+			f := &memFile{}
+			if err := NewEncoder(f, &opt).Encode16(data, width, length); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(expected.buf, f.buf) {
+				t.Errorf("%+v: file differs from file compressed one block at a time", opt)
+			}
+
+			var buf bytes.Buffer
+			if err := NewForwardEncoder(&buf, &opt).Encode16(data, width, length); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(forward.Bytes(), buf.Bytes()) {
+				t.Errorf("%+v: forward file differs from file compressed one block at a time", opt)
+			}
+
+			// rows written a few at a time
+			f = &memFile{}
+			s, err := NewStreamWriter(f, width, length, 16, &opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for y := 0; y < length; y += 10 {
+				end := (y + 10) * width
+				if end > len(data) {
+					end = len(data)
+				}
+				if err := s.Write16(data[y*width : end]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(expected.buf, f.buf) {
+				t.Errorf("%+v: streamed file differs from file compressed one block at a time", opt)
+			}
+		}
+	}
+}
+
+// writeCounter is an io.WriteSeeker that discards data and counts calls to Write
+type writeCounter struct {
+	pos, size int64
+	writes    int
+}
+
+func (w *writeCounter) Write(p []byte) (int, error) {
+	w.writes++
+	w.pos += int64(len(p))
+	if w.pos > w.size {
+		w.size = w.pos
+	}
+	return len(p), nil
+}
+
+func (w *writeCounter) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+		w.pos = offset
+	case io.SeekCurrent:
+		w.pos += offset
+	case io.SeekEnd:
+		w.pos = w.size + offset
+	}
+	return w.pos, nil
+}
+
+func TestEncodeBufferedWrites(t *testing.T) {
+	const width, length = 1000, 1000
+	data := make([]uint16, width*length)
+
+	for _, byteOrder := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		// 245 strips of about 8 KB are written in about 64 KB writes
+		w := &writeCounter{}
+		if err := NewEncoder(w, &Options{ByteOrder: byteOrder}).Encode16(data, width, length); err != nil {
+			t.Fatal(err)
+		}
+		if w.writes > 40 {
+			t.Errorf("%v: expected at most 40 writes, got %d", byteOrder, w.writes)
+		}
+
+		w = &writeCounter{}
+		if err := NewForwardEncoder(w, &Options{ByteOrder: byteOrder, Compression: CompressionLZW}).Encode16(data, width, length); err != nil {
+			t.Fatal(err)
+		}
+		if w.writes > 10 {
+			t.Errorf("%v: expected at most 10 forward writes, got %d", byteOrder, w.writes)
+		}
+	}
+}
+
+func TestWriteValidation(t *testing.T) {
+	data := make([]uint16, 6*4)
+	for i := range data {
+		data[i] = uint16(i)
+	}
+	for _, n := range []int{6*4 - 1, 6*4 + 1} {
+		samples := make([]uint16, n)
+		if err := WriteTiff16(&memFile{}, binary.LittleEndian, samples, 6, 4); !errors.Is(err, ErrDataSize) {
+			t.Errorf("%d samples: expected ErrDataSize, got %v", n, err)
+		}
+		if err := NewForwardEncoder(&bytes.Buffer{}, nil).Encode16(samples, 6, 4); !errors.Is(err, ErrDataSize) {
+			t.Errorf("%d samples forward: expected ErrDataSize, got %v", n, err)
+		}
+	}
+
+	// byte counts are in bytes, not samples
+	f := &memFile{}
+	if err := WriteTiff16(f, binary.LittleEndian, data, 6, 4); err != nil {
+		t.Fatal(err)
+	}
+	tags, _, err := ReadTags(bytes.NewReader(f.buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tags.StripByteCounts[0] != 2*6*4 {
+		t.Errorf("expected byte count %d, got %d", 2*6*4, tags.StripByteCounts[0])
+	}
+
+	// read back verification
+	for _, opt := range []Options{
+		{Verify: true},
+		{Verify: true, Compression: CompressionLZW, TileWidth: 16, TileLength: 16},
+	} {
+		if err := NewEncoder(&memFile{}, &opt).Encode16(data, 6, 4); err != nil {
+			t.Errorf("compression %d: %v", opt.Compression, err)
+		}
+		if err := NewEncoder(&corruptingFile{}, &opt).Encode16(data, 6, 4); !errors.Is(err, ErrVerify) {
+			t.Errorf("compression %d: expected ErrVerify, got %v", opt.Compression, err)
+		}
+	}
+	if err := NewForwardEncoder(&bytes.Buffer{}, &Options{Verify: true}).Encode16(data, 6, 4); err == nil {
+		t.Error("expected error verifying a writer that can't be read")
+	}
+}
+
+// corruptingFile flips a bit of the first pixel, which follows the header, as it is written
+type corruptingFile struct {
+	memFile
+}
+
+func (f *corruptingFile) Write(p []byte) (int, error) {
+	if i := 8 - f.off; i >= 0 && i < int64(len(p)) {
+		p = append([]byte(nil), p...)
+		p[i] ^= 1
+	}
+	return f.memFile.Write(p)
+}
+
+func benchmarkEncode32(b *testing.B, byteOrder binary.ByteOrder) {
+	const width, length = 4096, 4096
+	data := make([]float32, width*length)
+	b.SetBytes(4 * width * length)
+	for i := 0; i < b.N; i++ {
+		if err := NewEncoder(&writeCounter{}, &Options{ByteOrder: byteOrder}).Encode32(data, width, length); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncode32Native(b *testing.B)  { benchmarkEncode32(b, nativeEndian) }
+func BenchmarkEncode32Swapped(b *testing.B) { benchmarkEncode32(b, swappedEndian()) }
+
+func TestEncodeBigTIFFFields(t *testing.T) {
+	long8 := func(tag, dtype uint16, v uint64) Field {
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, v)
+		return Field{Tag: tag, Type: dtype, Count: 1, Value: b, ByteOrder: binary.BigEndian}
+	}
+	minus := uint64(1<<64 - 5) // -5 as an slong8
+
+	// a classic tiff holds 64 bit types as their 32 bit types
+	opt := Options{ExtraTags: []Field{long8(65000, 16, 7), long8(65001, 17, minus)}}
+	f := &memFile{}
+	if err := NewEncoder(f, &opt).Encode8(data8, 5, 5); err != nil {
+		t.Fatal(err)
+	}
+	f.Seek(0, io.SeekStart)
+	got, _, err := ReadTags(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if field, ok := got.Field(65000); !ok || field.Type != 4 || binary.LittleEndian.Uint32(field.Value) != 7 {
+		t.Errorf("expected long 7, got %+v", field)
+	}
+	if field, ok := got.Field(65001); !ok || field.Type != 9 || int32(binary.LittleEndian.Uint32(field.Value)) != -5 {
+		t.Errorf("expected slong -5, got %+v", field)
+	}
+
+	// unless the values don't fit, which needs BigTIFF
+	for _, field := range []Field{long8(65000, 16, 1<<32), long8(65000, 17, 1<<31), long8(65000, 18, 1<<40), {Tag: 65000, Type: 1, Count: 1 << 32}} {
+		opt := Options{ExtraTags: []Field{field}}
+		if err := NewEncoder(&memFile{}, &opt).Encode8(data8, 5, 5); err == nil {
+			t.Errorf("%d values of type %d: expected error", field.Count, field.Type)
+		}
+	}
+	opt = Options{ExtraTags: []Field{long8(65000, 16, 1<<40)}, BigTIFF: true}
+	f = &memFile{}
+	if err := NewEncoder(f, &opt).Encode8(data8, 5, 5); err != nil {
+		t.Fatal(err)
+	}
+	f.Seek(0, io.SeekStart)
+	if got, _, err = ReadTags(f); err != nil {
+		t.Fatal(err)
+	}
+	if field, ok := got.Field(65000); !ok || field.Type != 16 || binary.LittleEndian.Uint64(field.Value) != 1<<40 {
+		t.Errorf("expected long8 in BigTIFF, got %+v", field)
+	}
+}